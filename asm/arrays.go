@@ -3,6 +3,7 @@ package asm
 import (
 	"fmt"
 	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
 )
 
 type OffsetType int
@@ -24,6 +25,7 @@ func (i ArrayRef) Exec(p *Execution) {
 	switch i.OffsetType {
 	case OffsetTypeArray:
 		arr := p.Pop().([]any)
+		checkArrayIndex(arr, idx)
 		p.Push(&arr[idx])
 	default:
 		panic(i.OffsetType)
@@ -39,6 +41,12 @@ func (i ArrayRef) String() string {
 	}
 }
 
+func checkArrayIndex(arr []any, idx int64) {
+	if idx < 0 || idx >= int64(len(arr)) {
+		panic(lib.Errorf(lib.IndexOutOfRange, "index %d out of range for array of size %d", idx, len(arr)))
+	}
+}
+
 type ArrayVal struct {
 	baseInst
 	ast.SourceInfo
@@ -51,9 +59,10 @@ func (i ArrayVal) Exec(p *Execution) {
 	switch i.OffsetType {
 	case OffsetTypeString:
 		str := p.Pop().(string)
-		p.Push(str[idx])
+		p.Push(lib.CharAt(str, idx))
 	case OffsetTypeArray:
 		arr := p.Pop().([]any)
+		checkArrayIndex(arr, idx)
 		p.Push(arr[idx])
 	default:
 		panic(i.OffsetType)
@@ -86,7 +95,7 @@ func (n ArrayNew) Exec(p *Execution) {
 }
 
 func (n ArrayNew) String() string {
-	return "array new " + litTypes[n.Typ.Base.AsPrimitive()] + arrayTypeSized(n.Typ.NDims, n.Size)
+	return "array new " + litTypeName(n.Typ.Base) + arrayTypeSized(n.Typ.NDims, n.Size)
 }
 
 type ArrayClone struct {
@@ -114,7 +123,7 @@ func arrayClone(dims int, v []any) []any {
 }
 
 func (d ArrayClone) String() string {
-	return "array clone " + litTypes[d.Typ.Base.AsPrimitive()] + arrayTypeTail(d.NDims)
+	return "array clone " + litTypeName(d.Typ.Base) + arrayTypeTail(d.NDims)
 }
 
 func arrayTypeSized(dims int, sz int) string {
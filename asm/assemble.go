@@ -0,0 +1,696 @@
+package asm
+
+import (
+	"fmt"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Assemble reads assembly in the format written by [Assembly.Dump] back into a runnable program:
+// one instruction per line, each optionally preceded by its pc, followed by the function, class,
+// and string tables as comments. Instructions take their source line from the last "; N: ..."
+// comment before them if there is one, otherwise from their own line in the text.
+//
+// Functions missing from the function table get placeholder parameters and locals.
+func Assemble(text string) (as *Assembly, err error) {
+	a := &assembler{
+		types: typeTable{},
+		named: map[string]*Label{},
+		jumps: map[int]*Label{},
+		refs:  map[*Label]int{},
+		funcs: map[int]asmFunc{},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(asmError); ok {
+				as, err = nil, e
+				return
+			}
+			panic(r)
+		}
+	}()
+	return a.assemble(text), nil
+}
+
+type asmError struct {
+	Line int // 0-based
+	Msg  string
+}
+
+func (e asmError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Line+1, e.Msg)
+}
+
+// asmLine is a line of assembly text.
+type asmLine struct {
+	n    int // 0-based line in the text
+	src  int // 0-based source line, for instructions
+	text string
+}
+
+// asmFunc is an entry in the function table.
+type asmFunc struct {
+	asmLine
+	typ    string // return type, if a Function
+	params string
+	locals string
+}
+
+type assembler struct {
+	types   typeTable
+	global  *ast.Scope
+	named   map[string]*Label // labels of the global block, Modules, Functions, and methods
+	jumps   map[int]*Label    // jump targets, by pc
+	refs    map[*Label]int    // the first line referring to each named label or jump target, for errors
+	funcs   map[int]asmFunc   // function table entries, by starting pc
+	strings []string
+	classes []*ast.ClassStmt
+	vtables []Vtable
+	vtLbls  [][]*Label
+	code    []Inst
+	line    int // the line being assembled, for errors
+}
+
+func (a *assembler) errorf(format string, args ...any) {
+	panic(asmError{Line: a.line, Msg: fmt.Sprintf(format, args...)})
+}
+
+var (
+	sourceLineRe = regexp.MustCompile(`^;\s*(\d+):`)
+	pcPrefixRe   = regexp.MustCompile(`^(0x[0-9A-Fa-f]+)\s+(.*)$`)
+	funcEntryRe  = regexp.MustCompile(`^;(0x[0-9A-Fa-f]+)-(0x[0-9A-Fa-f]+):(?:([^(\s]+) )?([^(\s]+)\(([^)]*)\)(.*)$`)
+	classEntryRe = regexp.MustCompile(`^;\[(\d+)\] ([^(\s]+)\(([^)]*)\)(?: (.*))?$`)
+	strEntryRe   = regexp.MustCompile(`^;\[(\d+)\] (".*")$`)
+)
+
+func (a *assembler) assemble(text string) *Assembly {
+	var code, funcs, classes, strs []asmLine
+	section := ""
+	srcLine := -1
+	for n, line := range strings.Split(text, "\n") {
+		a.line = n
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "; -- "):
+			section = strings.TrimPrefix(line, "; -- ")
+			if section != "functions" && section != "classes" && section != "strings" {
+				a.errorf("unknown table %q", section)
+			}
+		case strings.HasPrefix(line, ";"):
+			l := asmLine{n: n, text: line}
+			switch section {
+			case "functions":
+				funcs = append(funcs, l)
+			case "classes":
+				classes = append(classes, l)
+			case "strings":
+				strs = append(strs, l)
+			default:
+				if m := sourceLineRe.FindStringSubmatch(line); m != nil {
+					srcLine = a.atoi(m[1]) - 1
+				}
+			}
+		default:
+			section = ""
+			src := n
+			if srcLine >= 0 {
+				src = srcLine
+			}
+			code = append(code, asmLine{n: n, src: src, text: line})
+		}
+	}
+
+	// the tables come last, but instructions depend on them
+	a.global = ast.NewGlobalScope(&ast.Block{})
+	a.assembleClasses(classes)
+	for i, l := range strs {
+		a.line = l.n
+		m := strEntryRe.FindStringSubmatch(l.text)
+		if m == nil {
+			a.errorf("expected string table entry, got %q", l.text)
+		}
+		if a.atoi(m[1]) != i {
+			a.errorf("expected string %d, got %s", i, m[1])
+		}
+		str, err := strconv.Unquote(m[2])
+		if err != nil {
+			a.errorf("bad string %s: %v", m[2], err)
+		}
+		a.strings = append(a.strings, str)
+	}
+	for _, l := range funcs {
+		a.line = l.n
+		m := funcEntryRe.FindStringSubmatch(l.text)
+		if m == nil {
+			a.errorf("expected function table entry, got %q", l.text)
+		}
+		pc := a.pc(m[1])
+		if _, ok := a.funcs[pc]; ok {
+			a.errorf("duplicate function table entry for %s", m[1])
+		}
+		a.funcs[pc] = asmFunc{asmLine: l, typ: m[3], params: m[5], locals: m[6]}
+	}
+
+	for pc, l := range code {
+		a.line = l.n
+		text := l.text
+		if m := pcPrefixRe.FindStringSubmatch(text); m != nil {
+			if got := a.pc(m[1]); got != pc {
+				a.errorf("expected instruction at %s, got %s", PcRef(pc), m[1])
+			}
+			text = m[2]
+		}
+		si := ast.SourceInfo{Start: ast.Position{Line: l.src}, End: ast.Position{Line: l.src}}
+		a.code = append(a.code, a.assembleInst(pc, text, si))
+	}
+
+	return a.finish(code)
+}
+
+func (a *assembler) assembleClasses(lines []asmLine) {
+	// declare all the classes first, since fields may refer to any of them
+	matches := make([][]string, len(lines))
+	for i, l := range lines {
+		a.line = l.n
+		m := classEntryRe.FindStringSubmatch(l.text)
+		if m == nil {
+			a.errorf("expected class table entry, got %q", l.text)
+		}
+		if a.atoi(m[1]) != i {
+			a.errorf("expected class %d, got %s", i, m[1])
+		}
+		if _, ok := a.types[m[2]]; ok {
+			a.errorf("duplicate class %s", m[2])
+		}
+		ct := &ast.ClassType{TypeKey: ast.TypeKey(m[2])}
+		cs := &ast.ClassStmt{
+			SourceInfo: ast.SourceInfo{Start: ast.Position{Line: l.n}, End: ast.Position{Line: l.n}},
+			Name:       m[2],
+			Type:       ct,
+			Block:      &ast.Block{},
+		}
+		cs.Scope = ast.NewClassScope(cs, a.global)
+		ct.Class, ct.Scope = cs, cs.Scope
+		a.global.AddClass(cs)
+		a.types[m[2]] = ct
+		a.classes = append(a.classes, cs)
+		matches[i] = m
+	}
+
+	a.vtables = make([]Vtable, len(lines))
+	a.vtLbls = make([][]*Label, len(lines))
+	for i, l := range lines {
+		a.line = l.n
+		m, cs := matches[i], a.classes[i]
+		for _, vd := range a.vars(m[3]) {
+			vd.Enclosing, vd.Scope, vd.Id = cs.Type, cs.Scope, len(cs.Scope.Fields)
+			cs.Scope.Fields = append(cs.Scope.Fields, vd)
+		}
+		if m[4] != "" {
+			for _, ref := range strings.Split(m[4], "|") {
+				pcRef, name, ok := strings.Cut(ref, ":")
+				if !ok {
+					a.errorf("expected pc:label, got %q", ref)
+				}
+				a.vtLbls[i] = append(a.vtLbls[i], a.namedLabel(name, a.pc(pcRef)))
+			}
+		}
+	}
+}
+
+func (a *assembler) finish(code []asmLine) *Assembly {
+	if len(a.code) == 0 {
+		a.errorf("no instructions")
+	}
+	if _, ok := a.code[0].(Begin); !ok {
+		a.line = code[0].n
+		a.errorf("the program must start with a begin")
+	}
+	// every begin needs its end, before the next begin
+	var open *Label
+	for pc, inst := range a.code {
+		a.line = code[pc].n
+		switch inst := inst.(type) {
+		case Begin:
+			if open != nil {
+				a.errorf("begin of %s before the end of %s", inst.Label.Name, open.Name)
+			}
+			open = inst.Label
+		case End:
+			if inst.Label != open {
+				a.errorf("end of %s does not match a begin", inst.Label.Name)
+			}
+			open = nil
+		}
+	}
+	if open != nil {
+		a.errorf("%s has no end", open.Name)
+	}
+	// check the tables in a stable order, so the first error reported is always the same
+	for _, pc := range sortedKeys(a.funcs) {
+		if pc >= len(a.code) {
+			a.line = a.funcs[pc].n
+			a.errorf("function table entry %s is past the end of the program", PcRef(pc))
+		}
+	}
+
+	labels := map[string]*Label{}
+	for _, name := range sortedKeys(a.named) {
+		lbl := a.named[name]
+		if lbl.PC < len(a.code) {
+			if be, ok := a.code[lbl.PC].(Begin); ok && be.Label == lbl {
+				if lbl.PC != 0 {
+					labels[name] = lbl
+				}
+				continue
+			}
+		}
+		a.line = a.refs[lbl]
+		a.errorf("label %s: no begin at %s", name, PcRef(lbl.PC))
+	}
+	for _, pc := range sortedKeys(a.jumps) {
+		if pc >= len(a.code) {
+			a.line = a.refs[a.jumps[pc]]
+			a.errorf("jump to %s is past the end of the program", PcRef(pc))
+		}
+	}
+	for i, lbls := range a.vtLbls {
+		for _, lbl := range lbls {
+			a.vtables[i] = append(a.vtables[i], lbl.PC)
+		}
+	}
+
+	classes := make([]string, len(a.classes))
+	for i, cs := range a.classes {
+		classes[i] = cs.Name
+	}
+	return &Assembly{
+		GlobalScope: a.global,
+		Code:        a.code,
+		Labels:      labels,
+		Strings:     a.strings,
+		Classes:     classes,
+		Vtables:     a.vtLbls,
+	}
+}
+
+var (
+	beginRe     = regexp.MustCompile(`^begin\((\d+),(\d+)\) :(\S+)$`)
+	endRe       = regexp.MustCompile(`^end :(\S+)$`)
+	haltRe      = regexp.MustCompile(`^halt\((\d+)\)$`)
+	jumpRe      = regexp.MustCompile(`^jump(?: (false|true))? (0x[0-9A-Fa-f]+)$`)
+	callRe      = regexp.MustCompile(`^call\((\d+)\) (0x[0-9A-Fa-f]+):(\S+)$`)
+	returnRe    = regexp.MustCompile(`^return\((\d+)\)$`)
+	libCallRe   = regexp.MustCompile(`^libcall\((\d+)\) (\d+):(\S+)$`)
+	vcallRe     = regexp.MustCompile(`^vcall\[(\d+)\]\((\d+)\) ([^.\s]+)\.(\S+)$`)
+	objNewRe    = regexp.MustCompile(`^object new (\S+)$`)
+	fieldRefRe  = regexp.MustCompile(`^&field\[(\d+)\] #(\S+)$`)
+	fieldValRe  = regexp.MustCompile(`^field\[(\d+)\] (\S*)#(\S+)$`)
+	varRe       = regexp.MustCompile(`^([&*]?)(global|param|local)\[(\d+)\] #(\S+)$`)
+	literalRe   = regexp.MustCompile(`^literal (\S+) (.+)$`)
+	arrayNewRe  = regexp.MustCompile(`^array new ([^\[\s]+)\[(\d+)\]((?:\[\])*)$`)
+	arrayCopyRe = regexp.MustCompile(`^array clone ([^\[\s]+)((?:\[\])+)$`)
+	dupRe       = regexp.MustCompile(`^dup\((\d+)\)$`)
+	incrRe      = regexp.MustCompile(`^incr (int|real) (\S+)$`)
+	opRe        = regexp.MustCompile(`^([a-z]+)(?: (int|real|str|char|bool))?$`)
+)
+
+// litTypeNames maps the type names in instructions back to types.
+var litTypeNames = map[string]ast.Type{"chr": ast.Character}
+
+func init() {
+	for _, typ := range []ast.Type{
+		ast.Integer, ast.Real, ast.String, ast.Character, ast.Boolean,
+		ast.OutputFile, ast.AppendFile, ast.InputFile, ast.RandomFile,
+	} {
+		litTypeNames[litTypeName(typ)] = typ
+	}
+}
+
+func (a *assembler) assembleInst(pc int, text string, si ast.SourceInfo) Inst {
+	switch text {
+	case "halt":
+		return Halt{SourceInfo: si}
+	case "exit":
+		return Halt{SourceInfo: si, NVal: 1, Exit: true}
+	case "return":
+		return Return{SourceInfo: si}
+	case "store":
+		return Store{SourceInfo: si}
+	case "pop":
+		return Pop{SourceInfo: si}
+	case "deref":
+		return Deref{SourceInfo: si}
+	case "dup":
+		return Dup{SourceInfo: si}
+	case "&array":
+		return ArrayRef{SourceInfo: si, OffsetType: OffsetTypeArray}
+	case "array":
+		return ArrayVal{SourceInfo: si, OffsetType: OffsetTypeArray}
+	case "string":
+		return ArrayVal{SourceInfo: si, OffsetType: OffsetTypeString}
+	case "array len":
+		return ArrayLen{SourceInfo: si}
+	case "conv int real":
+		return IntToReal{SourceInfo: si}
+	case "conv real int":
+		return RealToInt{SourceInfo: si}
+	case "conv char str":
+		return CharToString{SourceInfo: si}
+	}
+
+	if m := beginRe.FindStringSubmatch(text); m != nil {
+		lbl := a.namedLabel(m[3], pc)
+		nParams, nLocals := a.atoi(m[1]), a.atoi(m[2])
+		return Begin{SourceInfo: si, Scope: a.scope(pc, lbl, nParams, nLocals, si), Label: lbl, NParams: nParams, NLocals: nLocals}
+	} else if m := endRe.FindStringSubmatch(text); m != nil {
+		lbl, ok := a.named[m[1]]
+		if !ok {
+			a.errorf("end of %s does not match a begin", m[1])
+		}
+		return End{SourceInfo: si, Label: lbl}
+	} else if m := haltRe.FindStringSubmatch(text); m != nil {
+		return Halt{SourceInfo: si, NVal: a.atoi(m[1])}
+	} else if m := jumpRe.FindStringSubmatch(text); m != nil {
+		lbl := a.jumpLabel(a.pc(m[2]))
+		switch m[1] {
+		case "false":
+			return JumpFalse{SourceInfo: si, Label: lbl}
+		case "true":
+			return JumpTrue{SourceInfo: si, Label: lbl}
+		default:
+			return Jump{SourceInfo: si, Label: lbl}
+		}
+	} else if m := callRe.FindStringSubmatch(text); m != nil {
+		return Call{SourceInfo: si, Label: a.namedLabel(m[3], a.pc(m[2])), NArgs: a.atoi(m[1])}
+	} else if m := returnRe.FindStringSubmatch(text); m != nil {
+		return Return{SourceInfo: si, NVal: a.atoi(m[1])}
+	} else if m := libCallRe.FindStringSubmatch(text); m != nil {
+		index := a.atoi(m[2])
+		if !lib.IsNamed(index, m[3]) {
+			a.errorf("library function %d is not %s", index, m[3])
+		}
+		// the result type isn't in the text; it's only for describing errors
+		return LibCall{SourceInfo: si, Name: m[3], Type: ast.UnresolvedType, Index: index, NArg: a.atoi(m[1])}
+	} else if m := vcallRe.FindStringSubmatch(text); m != nil {
+		return VCall{SourceInfo: si, Class: m[3], Name: m[4], Index: a.atoi(m[1]), NArgs: a.atoi(m[2])}
+	} else if m := objNewRe.FindStringSubmatch(text); m != nil {
+		ct, err := a.types.classType(m[1])
+		if err != nil {
+			a.errorf("%v", err)
+		}
+		return ObjNew{SourceInfo: si, Type: ct, Vtable: &a.vtables[ct.Class.Id], NFields: len(ct.Scope.Fields)}
+	} else if m := fieldRefRe.FindStringSubmatch(text); m != nil {
+		return FieldRef{SourceInfo: si, Name: m[2], Index: a.atoi(m[1])}
+	} else if m := fieldValRe.FindStringSubmatch(text); m != nil {
+		return FieldVal{SourceInfo: si, Class: m[2], Name: m[3], Index: a.atoi(m[1])}
+	} else if m := varRe.FindStringSubmatch(text); m != nil {
+		return a.assembleVar(m[1], m[2], a.atoi(m[3]), m[4], si, text)
+	} else if m := literalRe.FindStringSubmatch(text); m != nil {
+		return a.assembleLiteral(m[1], m[2], si)
+	} else if m := arrayNewRe.FindStringSubmatch(text); m != nil {
+		nDims := 1 + strings.Count(m[3], "[]")
+		return &ArrayNew{SourceInfo: si, Typ: a.arrayType(m[1], nDims), Size: a.atoi(m[2])}
+	} else if m := arrayCopyRe.FindStringSubmatch(text); m != nil {
+		nDims := strings.Count(m[2], "[]")
+		return ArrayClone{SourceInfo: si, Typ: a.arrayType(m[1], nDims), NDims: nDims}
+	} else if m := dupRe.FindStringSubmatch(text); m != nil {
+		return Dup{SourceInfo: si, Skip: a.atoi(m[1])}
+	} else if m := incrRe.FindStringSubmatch(text); m != nil {
+		if m[1] == "int" {
+			val, err := strconv.ParseInt(m[2], 10, 64)
+			if err != nil {
+				a.errorf("bad increment %s", m[2])
+			}
+			return IncrInt{SourceInfo: si, Val: val}
+		}
+		val, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			a.errorf("bad increment %s", m[2])
+		}
+		return IncrReal{SourceInfo: si, Val: val}
+	} else if m := opRe.FindStringSubmatch(text); m != nil {
+		if op, err := lookupOperator(m[1]); err == nil {
+			if inst := makeOp(op, m[2], si); inst != nil {
+				return inst
+			}
+		}
+	}
+	a.errorf("unknown instruction %q", text)
+	return nil
+}
+
+// makeOp makes an operator instruction, or returns nil if the operator doesn't apply to the type.
+func makeOp(op ast.Operator, typ string, si ast.SourceInfo) Inst {
+	switch op {
+	case ast.NEG:
+		switch typ {
+		case "int":
+			return UnaryOpInt{SourceInfo: si, Op: op}
+		case "real":
+			return UnaryOpFloat{SourceInfo: si, Op: op}
+		}
+	case ast.NOT:
+		if typ == "" {
+			return UnaryOpBool{SourceInfo: si, Op: op}
+		}
+	default:
+		switch typ {
+		case "int":
+			return BinOpInt{SourceInfo: si, Op: op}
+		case "real":
+			return BinOpReal{SourceInfo: si, Op: op}
+		case "str":
+			return BinOpStr{SourceInfo: si, Op: op}
+		case "char":
+			return BinOpChar{SourceInfo: si, Op: op}
+		case "bool":
+			return BinOpBool{SourceInfo: si, Op: op}
+		}
+	}
+	return nil
+}
+
+func (a *assembler) assembleVar(prefix string, kind string, index int, name string, si ast.SourceInfo, text string) Inst {
+	switch prefix + kind {
+	case "&global":
+		return GlobalRef{SourceInfo: si, Name: name, Index: index}
+	case "global":
+		return GlobalVal{SourceInfo: si, Name: name, Index: index}
+	case "&param":
+		return ParamRef{SourceInfo: si, Name: name, Index: index}
+	case "param":
+		return ParamVal{SourceInfo: si, Name: name, Index: index}
+	case "*param":
+		return ParamPtr{SourceInfo: si, Name: name, Index: index}
+	case "&local":
+		return LocalRef{SourceInfo: si, Name: name, Index: index}
+	case "local":
+		return LocalVal{SourceInfo: si, Name: name, Index: index}
+	}
+	a.errorf("unknown instruction %q", text)
+	return nil
+}
+
+func (a *assembler) assembleLiteral(typName string, val string, si ast.SourceInfo) Inst {
+	typ, ok := litTypeNames[typName]
+	if !ok {
+		ct, err := a.types.classType(typName)
+		if err != nil {
+			a.errorf("unknown literal type %s", typName)
+		}
+		if val != "nil" {
+			a.errorf("expected nil %s literal, got %s", typName, val)
+		}
+		return Literal{SourceInfo: si, Typ: ct, Val: ZeroValue(ct)}
+	}
+
+	lit := Literal{SourceInfo: si, Typ: typ}
+	var err error
+	switch typ {
+	case ast.Integer:
+		lit.Val, err = strconv.ParseInt(val, 10, 64)
+	case ast.Real:
+		lit.Val, err = strconv.ParseFloat(val, 64)
+	case ast.String:
+		if val == "tab" {
+			lit.Val = lib.TabDisplay
+		} else if ref, ok := strings.CutPrefix(val, "["); !ok || !strings.HasSuffix(ref, "]") {
+			a.errorf("expected tab or [string], got %s", val)
+		} else if id, err := strconv.Atoi(strings.TrimSuffix(ref, "]")); err != nil || id < 0 || id >= len(a.strings) {
+			a.errorf("unknown string %s", val)
+		} else {
+			lit.Val, lit.Id = a.strings[id], id
+		}
+	case ast.Character:
+		var str string
+		if str, err = strconv.Unquote(val); err == nil {
+			if r := []rune(str); len(r) == 1 && strings.HasPrefix(val, "'") {
+				lit.Val = r[0]
+			} else {
+				a.errorf("expected a character, got %s", val)
+			}
+		}
+	case ast.Boolean:
+		lit.Val, err = strconv.ParseBool(val)
+	default:
+		if val != "{}" {
+			a.errorf("expected {} %s literal, got %s", typName, val)
+		}
+		lit.Val = ZeroValue(typ)
+	}
+	if err != nil {
+		a.errorf("bad %s literal %s", typName, val)
+	}
+	return lit
+}
+
+func (a *assembler) arrayType(baseName string, nDims int) *ast.ArrayType {
+	base, ok := litTypeNames[baseName]
+	if !ok {
+		ct, err := a.types.classType(baseName)
+		if err != nil {
+			a.errorf("unknown array type %s", baseName)
+		}
+		base = ct
+	}
+	at, err := a.types.arrayType(string(base.Key()) + strings.Repeat("[]", nDims))
+	if err != nil {
+		a.errorf("%v", err)
+	}
+	return at
+}
+
+// namedLabel returns the label of the global block, a Module, a Function, or a method, which must
+// be at the same pc wherever it's referenced.
+func (a *assembler) namedLabel(name string, pc int) *Label {
+	if lbl, ok := a.named[name]; ok {
+		if lbl.PC != pc {
+			a.errorf("label %s is at %s, not %s", name, PcRef(lbl.PC), PcRef(pc))
+		}
+		return lbl
+	}
+	lbl := &Label{Name: name, PC: pc}
+	a.named[name] = lbl
+	a.refs[lbl] = a.line
+	return lbl
+}
+
+// jumpLabel returns the label for a jump target; the original names aren't in the text.
+func (a *assembler) jumpLabel(pc int) *Label {
+	if lbl, ok := a.jumps[pc]; ok {
+		return lbl
+	}
+	lbl := &Label{Name: PcRef(pc), PC: pc}
+	a.jumps[pc] = lbl
+	a.refs[lbl] = a.line
+	return lbl
+}
+
+// scope makes the scope for a begin, from the function table if it has an entry.
+func (a *assembler) scope(pc int, lbl *Label, nParams int, nLocals int, si ast.SourceInfo) *ast.Scope {
+	f, ok := a.funcs[pc]
+	var s *ast.Scope
+	if pc == 0 {
+		s = a.global
+		s.SourceInfo = si
+	} else {
+		// methods are labeled Class$name
+		name, parent := lbl.Name, a.global
+		var enclosing *ast.ClassType
+		if class, method, ok := strings.Cut(name, "$"); ok {
+			if ct, err := a.types.classType(class); err == nil {
+				name, parent, enclosing = method, ct.Scope, ct
+			}
+		}
+		body := &ast.Block{SourceInfo: si}
+		if f.typ != "" {
+			typ, err := a.types.lookup(f.typ)
+			if err != nil {
+				a.errorf("%v", err)
+			}
+			fs := &ast.FunctionStmt{SourceInfo: si, Name: name, Type: typ, Block: body, Enclosing: enclosing}
+			s = ast.NewFunctionScope(fs, parent)
+			fs.Scope = s
+		} else {
+			ms := &ast.ModuleStmt{SourceInfo: si, Name: name, Block: body, Enclosing: enclosing}
+			ms.IsConstructor = enclosing != nil && name == enclosing.GetName()
+			s = ast.NewModuleScope(ms, parent)
+			ms.Scope = s
+		}
+	}
+
+	var params, locals []*ast.VarDecl
+	if ok {
+		params, locals = a.vars(f.params), a.vars(f.locals)
+	} else {
+		for i := 0; i < nParams; i++ {
+			params = append(params, &ast.VarDecl{Name: fmt.Sprintf("param%d", i), Type: ast.UnresolvedType})
+		}
+		for i := 0; i < nLocals; i++ {
+			locals = append(locals, &ast.VarDecl{Name: fmt.Sprintf("local%d", i), Type: ast.UnresolvedType})
+		}
+	}
+	if len(params) != nParams || len(locals) != nLocals {
+		a.errorf("begin has %d params and %d locals, but the function table has %d and %d", nParams, nLocals, len(params), len(locals))
+	}
+	for _, vd := range params {
+		vd.IsParam, vd.Scope, vd.Id = true, s, len(s.Params)
+		s.Params = append(s.Params, vd)
+	}
+	for _, vd := range locals {
+		vd.Scope, vd.Id = s, len(s.Locals)
+		s.Locals = append(s.Locals, vd)
+	}
+	if s.ModuleStmt != nil {
+		s.ModuleStmt.Params = s.Params
+	} else if s.FunctionStmt != nil {
+		s.FunctionStmt.Params = s.Params
+	}
+	return s
+}
+
+// vars parses a list of variables from a table, as in "Integer#x|Real Ref#y".
+func (a *assembler) vars(list string) []*ast.VarDecl {
+	if list == "" {
+		return nil
+	}
+	var ret []*ast.VarDecl
+	for _, v := range strings.Split(list, "|") {
+		typName, name, ok := strings.Cut(v, "#")
+		if !ok {
+			a.errorf("expected Type#name, got %q", v)
+		}
+		typName, isRef := strings.CutSuffix(typName, " Ref")
+		typ, err := a.types.lookup(typName)
+		if err != nil {
+			a.errorf("%v", err)
+		}
+		ret = append(ret, &ast.VarDecl{Name: name, Type: typ, IsRef: isRef})
+	}
+	return ret
+}
+
+func (a *assembler) pc(ref string) int {
+	pc := RefPc(ref)
+	if pc < 0 {
+		a.errorf("bad address %s", ref)
+	}
+	// RefPc rounds down, but every instruction is 4 bytes
+	if addr, _ := strconv.ParseUint(ref, 0, 64); (addr-0x1000)%4 != 0 {
+		a.errorf("unaligned address %s", ref)
+	}
+	return pc
+}
+
+func (a *assembler) atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		a.errorf("bad number %s", s)
+	}
+	return n
+}
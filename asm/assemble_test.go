@@ -0,0 +1,101 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+const doubleAsm = `; double 21
+begin(0,0) :global$
+literal int 21
+call(1) 0x1014:double
+exit
+end :global$
+
+begin(1,0) :double
+param[0] #n
+dup
+add int
+return(1)
+end :double
+`
+
+func TestAssemble(t *testing.T) {
+	as, err := Assemble(doubleAsm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbl := as.Labels["double"]; lbl == nil || lbl.PC != 5 {
+		t.Errorf("got labels %v", as.Labels)
+	}
+	if si := as.Code[5].GetSourceInfo(); si.Start.Line != 7 {
+		t.Errorf("got source line %d, want 7", si.Start.Line)
+	}
+	if scope := as.Code[5].(Begin).Scope; scope.String() != "Module double" || scope.Params[0].Name != "param0" {
+		t.Errorf("got scope %s with params %v", scope, scope.Params)
+	}
+
+	p := as.NewExecution(&ExecutionContext{})
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if p.ExitCode != 42 {
+		t.Errorf("got exit code %d, want 42", p.ExitCode)
+	}
+
+	// assembling the dump gives the same dump
+	dump := as.Dump(doubleAsm)
+	again, err := Assemble(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := again.Dump(doubleAsm); got != dump {
+		t.Errorf("got=\n%s\nwant=\n%s", got, dump)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tcs := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"unknown", "begin(0,0) :global$\nfrob int\n", "2: unknown instruction"},
+		{"pc", "0x1000 begin(0,0) :global$\n0x1008 halt\n", "2: expected instruction at 0x1004"},
+		{"start", "halt\n", "1: the program must start with a begin"},
+		{"end", "begin(0,0) :global$\nhalt\n", "2: global$ has no end"},
+		{"label", "begin(0,0) :global$\ncall(0) 0x1004:global$\nend :global$\n", "2: label global$ is at 0x1000, not 0x1004"},
+		{"missing", "begin(0,0) :global$\ncall(0) 0x1008:f\nend :global$\n", "2: label f: no begin at 0x1008"},
+		{"jump", "begin(0,0) :global$\njump 0x1010\nend :global$\n", "2: jump to 0x1010 is past the end"},
+		{"unaligned", "begin(0,0) :global$\njump 0x1006\nend :global$\n", "2: unaligned address 0x1006"},
+		{"unaligned pc", "0x1000 begin(0,0) :global$\n0x1005 end :global$\n", "2: unaligned address 0x1005"},
+		{"string", "begin(0,0) :global$\nliteral str [0]\n", "2: unknown string [0]"},
+		{"libcall", "begin(0,0) :global$\nlibcall(1) 0:Print\n", "2: library function 0 is not Print"},
+		{"table", "begin(1,0) :global$\nend :global$\n; -- functions\n;0x1000-0x1000:global$()\n", "1: begin has 1 params and 0 locals"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Assemble(tc.text)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAssembleErrorOrder(t *testing.T) {
+	// with several bad table entries, the first by name or pc is always the one reported
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{"begin(0,0) :global$\ncall(0) 0x1010:g\ncall(0) 0x100c:f\ncall(0) 0x1014:h\nend :global$\n", "3: label f: no begin at 0x100C"},
+		{"begin(0,0) :global$\njump 0x1020\njump 0x1014\njump 0x1018\nend :global$\n", "3: jump to 0x1014 is past the end of the program"},
+	} {
+		for range 20 {
+			if _, err := Assemble(tc.text); err == nil || err.Error() != tc.want {
+				t.Fatalf("got %v, want %q", err, tc.want)
+			}
+		}
+	}
+}
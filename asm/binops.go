@@ -75,8 +75,8 @@ type BinOpChar struct {
 }
 
 func (i BinOpChar) Exec(p *Execution) {
-	b := p.Pop().(byte)
-	a := p.Pop().(byte)
+	b := p.Pop().(rune)
+	a := p.Pop().(rune)
 	p.Push(ast.CharacterOp(i.Op, a, b))
 }
 
@@ -0,0 +1,851 @@
+package asm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BytecodeFormat identifies a bytecode file written by [Assembly.WriteBytecode].
+const BytecodeFormat = "gaddis-bytecode"
+
+// BytecodeVersion is the version of the bytecode format; bump it whenever the instruction set or
+// the encoding changes, since older files can't be run by a newer VM or vice versa.
+const BytecodeVersion = 1
+
+// The bytecode is JSON. Types are referenced by key ("Integer", "Dog", "Real[][]"); labels and
+// scopes by index, with the global scope first. Label names aren't unique ("endif"), but the
+// labels of Modules, Functions, and methods have a key.
+type bcFile struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Source  string    `json:"source,omitempty"` // the source file name, for error messages
+	Strings []string  `json:"strings,omitempty"`
+	Classes []bcClass `json:"classes,omitempty"`
+	Scopes  []bcScope `json:"scopes"`
+	Labels  []bcLabel `json:"labels,omitempty"`
+	Code    []bcInst  `json:"code"`
+}
+
+type bcClass struct {
+	Name    string  `json:"name"`
+	Extends string  `json:"extends,omitempty"`
+	Fields  []bcVar `json:"fields,omitempty"`
+	Vtable  []int   `json:"vtable,omitempty"` // method labels
+	Src     bcSrc   `json:"src"`
+	BodySrc bcSrc   `json:"bodySrc"`
+}
+
+type bcScope struct {
+	Kind        string  `json:"kind"` // global, module, or function
+	Name        string  `json:"name,omitempty"`
+	Type        string  `json:"type,omitempty"`  // function return type
+	Class       string  `json:"class,omitempty"` // enclosing class, for methods
+	Constructor bool    `json:"constructor,omitempty"`
+	Params      []bcVar `json:"params,omitempty"`
+	Locals      []bcVar `json:"locals,omitempty"`
+	Src         bcSrc   `json:"src"` // the declaration
+	BodySrc     bcSrc   `json:"bodySrc"`
+}
+
+type bcVar struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Ref   bool   `json:"ref,omitempty"`
+	Const bool   `json:"const,omitempty"`
+	Src   bcSrc  `json:"src"`
+}
+
+type bcLabel struct {
+	Name string `json:"name"`
+	PC   int    `json:"pc"`
+	Key  string `json:"key,omitempty"` // the key in [Assembly.Labels], if any
+}
+
+// bcInst is the union of every instruction's operands; each op uses only some of them.
+type bcInst struct {
+	Op    string `json:"op"`
+	Src   bcSrc  `json:"src"`
+	Name  string `json:"name,omitempty"`
+	Class string `json:"class,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Val   string `json:"val,omitempty"`
+	Label int    `json:"label,omitempty"`
+	Scope int    `json:"scope,omitempty"`
+	Index int    `json:"index,omitempty"`
+	N     int    `json:"n,omitempty"` // a count: args, params, values, fields, dims, size, or skip
+	M     int    `json:"m,omitempty"` // a second count: locals
+	Exit  bool   `json:"exit,omitempty"`
+	Tab   bool   `json:"tab,omitempty"` // a Tab literal
+}
+
+// bcSrc is a compact ast.SourceInfo: start line, column, pos, then end line, column, pos.
+type bcSrc [6]int
+
+func toBcSrc(si ast.SourceInfo) bcSrc {
+	return bcSrc{si.Start.Line, si.Start.Column, si.Start.Pos, si.End.Line, si.End.Column, si.End.Pos}
+}
+
+func (s bcSrc) sourceInfo() ast.SourceInfo {
+	return ast.SourceInfo{
+		Start: ast.Position{Line: s[0], Column: s[1], Pos: s[2]},
+		End:   ast.Position{Line: s[3], Column: s[4], Pos: s[5]},
+	}
+}
+
+// WriteBytecode writes the assembly in the versioned bytecode format, which [ReadBytecode] can
+// load and run without the source. Filename is the name of the source file, kept for error
+// messages and stack traces.
+func (as *Assembly) WriteBytecode(w io.Writer, filename string) error {
+	e := bcEncoder{
+		scopes: map[*ast.Scope]int{},
+		labels: map[*Label]int{},
+	}
+	f := bcFile{
+		Format:  BytecodeFormat,
+		Version: BytecodeVersion,
+		Source:  filename,
+		Strings: as.Strings,
+	}
+
+	e.addScope(as.GlobalScope)
+	for _, c := range as.GlobalScope.Classes {
+		bc := bcClass{
+			Name:    c.Name,
+			Extends: c.Extends,
+			Src:     toBcSrc(c.SourceInfo),
+			BodySrc: toBcSrc(c.Scope.SourceInfo),
+		}
+		for _, vd := range c.Scope.Fields {
+			bc.Fields = append(bc.Fields, toBcVar(vd))
+		}
+		for _, lbl := range as.Vtables[c.Id] {
+			bc.Vtable = append(bc.Vtable, e.addLabel(lbl))
+		}
+		f.Classes = append(f.Classes, bc)
+	}
+
+	for pc, inst := range as.Code {
+		bi, err := e.encodeInst(inst)
+		if err != nil {
+			return fmt.Errorf("%s: %w", PcRef(pc), err)
+		}
+		f.Code = append(f.Code, bi)
+	}
+	f.Scopes = e.scopeList
+
+	// key the labels from the symbol table, in a stable order
+	var keys []string
+	for key := range as.Labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		e.labelList[e.addLabel(as.Labels[key])].Key = key
+	}
+	f.Labels = e.labelList
+
+	return json.NewEncoder(w).Encode(&f)
+}
+
+type bcEncoder struct {
+	scopes    map[*ast.Scope]int
+	scopeList []bcScope
+	labels    map[*Label]int
+	labelList []bcLabel
+}
+
+func (e *bcEncoder) addLabel(lbl *Label) int {
+	if i, ok := e.labels[lbl]; ok {
+		return i
+	}
+	i := len(e.labelList)
+	e.labels[lbl] = i
+	e.labelList = append(e.labelList, bcLabel{Name: lbl.Name, PC: lbl.PC})
+	return i
+}
+
+func (e *bcEncoder) addScope(s *ast.Scope) int {
+	if i, ok := e.scopes[s]; ok {
+		return i
+	}
+	bs := bcScope{
+		Src:     toBcSrc(s.SourceInfo),
+		BodySrc: toBcSrc(s.SourceInfo),
+	}
+	var enclosing *ast.ClassType
+	switch {
+	case s.IsGlobal:
+		bs.Kind = "global"
+	case s.ModuleStmt != nil:
+		bs.Kind = "module"
+		bs.Name = s.ModuleStmt.Name
+		bs.Constructor = s.ModuleStmt.IsConstructor
+		bs.Src = toBcSrc(s.ModuleStmt.SourceInfo)
+		enclosing = s.ModuleStmt.Enclosing
+	case s.FunctionStmt != nil:
+		bs.Kind = "function"
+		bs.Name = s.FunctionStmt.Name
+		bs.Type = string(s.FunctionStmt.Type.Key())
+		bs.Src = toBcSrc(s.FunctionStmt.SourceInfo)
+		enclosing = s.FunctionStmt.Enclosing
+	default:
+		panic(s.Desc())
+	}
+	if enclosing != nil {
+		bs.Class = enclosing.GetName()
+	}
+	for _, vd := range s.Params {
+		bs.Params = append(bs.Params, toBcVar(vd))
+	}
+	for _, vd := range s.Locals {
+		bs.Locals = append(bs.Locals, toBcVar(vd))
+	}
+
+	i := len(e.scopeList)
+	e.scopes[s] = i
+	e.scopeList = append(e.scopeList, bs)
+	return i
+}
+
+func toBcVar(vd *ast.VarDecl) bcVar {
+	return bcVar{
+		Name:  vd.Name,
+		Type:  string(vd.Type.Key()),
+		Ref:   vd.IsRef,
+		Const: vd.IsConst,
+		Src:   toBcSrc(vd.SourceInfo),
+	}
+}
+
+func (e *bcEncoder) encodeInst(inst Inst) (bcInst, error) {
+	bi := bcInst{Src: toBcSrc(inst.GetSourceInfo())}
+	switch i := inst.(type) {
+	case ArrayRef:
+		bi.Op, bi.Index = "ArrayRef", int(i.OffsetType)
+	case ArrayVal:
+		bi.Op, bi.Index = "ArrayVal", int(i.OffsetType)
+	case *ArrayNew:
+		bi.Op, bi.Type, bi.N = "ArrayNew", string(i.Typ.Key()), i.Size
+	case ArrayClone:
+		bi.Op, bi.Type, bi.N = "ArrayClone", string(i.Typ.Key()), i.NDims
+	case ArrayLen:
+		bi.Op = "ArrayLen"
+	case Store:
+		bi.Op = "Store"
+	case BinOpInt:
+		bi.Op, bi.Val = "BinOpInt", i.Op.Name()
+	case BinOpReal:
+		bi.Op, bi.Val = "BinOpReal", i.Op.Name()
+	case BinOpStr:
+		bi.Op, bi.Val = "BinOpStr", i.Op.Name()
+	case BinOpChar:
+		bi.Op, bi.Val = "BinOpChar", i.Op.Name()
+	case BinOpBool:
+		bi.Op, bi.Val = "BinOpBool", i.Op.Name()
+	case UnaryOpInt:
+		bi.Op, bi.Val = "UnaryOpInt", i.Op.Name()
+	case UnaryOpFloat:
+		bi.Op, bi.Val = "UnaryOpFloat", i.Op.Name()
+	case UnaryOpBool:
+		bi.Op, bi.Val = "UnaryOpBool", i.Op.Name()
+	case IncrInt:
+		bi.Op, bi.Val = "IncrInt", strconv.FormatInt(i.Val, 10)
+	case IncrReal:
+		bi.Op, bi.Val = "IncrReal", strconv.FormatFloat(i.Val, 'g', -1, 64)
+	case ObjNew:
+		bi.Op, bi.Class, bi.N = "ObjNew", i.Type.GetName(), i.NFields
+	case FieldRef:
+		bi.Op, bi.Name, bi.Index = "FieldRef", i.Name, i.Index
+	case FieldVal:
+		bi.Op, bi.Class, bi.Name, bi.Index = "FieldVal", i.Class, i.Name, i.Index
+	case VCall:
+		bi.Op, bi.Class, bi.Name, bi.Index, bi.N = "VCall", i.Class, i.Name, i.Index, i.NArgs
+	case Begin:
+		bi.Op, bi.Label, bi.Scope, bi.N, bi.M = "Begin", e.addLabel(i.Label), e.addScope(i.Scope), i.NParams, i.NLocals
+	case End:
+		bi.Op, bi.Label = "End", e.addLabel(i.Label)
+	case Halt:
+		bi.Op, bi.N, bi.Exit = "Halt", i.NVal, i.Exit
+	case Jump:
+		bi.Op, bi.Label = "Jump", e.addLabel(i.Label)
+	case JumpFalse:
+		bi.Op, bi.Label = "JumpFalse", e.addLabel(i.Label)
+	case JumpTrue:
+		bi.Op, bi.Label = "JumpTrue", e.addLabel(i.Label)
+	case IntToReal:
+		bi.Op = "IntToReal"
+	case RealToInt:
+		bi.Op = "RealToInt"
+	case CharToString:
+		bi.Op = "CharToString"
+	case Call:
+		bi.Op, bi.Label, bi.N = "Call", e.addLabel(i.Label), i.NArgs
+	case Return:
+		bi.Op, bi.N = "Return", i.NVal
+	case LibCall:
+		// the library's own name for the function, which differs from the Gaddis name of an overload
+		bi.Op, bi.Name, bi.Val, bi.Type, bi.N = "LibCall", i.Name, lib.NameAt(i.Index), string(i.Type.Key()), i.NArg
+	case Literal:
+		bi.Op, bi.Type = "Literal", string(i.Typ.Key())
+		switch i.Typ {
+		case ast.Integer:
+			bi.Val = strconv.FormatInt(i.Val.(int64), 10)
+		case ast.Real:
+			bi.Val = strconv.FormatFloat(i.Val.(float64), 'g', -1, 64)
+		case ast.String:
+			if i.Val == lib.TabDisplay {
+				bi.Tab = true
+			} else {
+				bi.Val, bi.Index = i.Val.(string), i.Id
+			}
+		case ast.Character:
+			bi.Val = string(i.Val.(rune))
+		case ast.Boolean:
+			bi.Val = strconv.FormatBool(i.Val.(bool))
+		case ast.OutputFile, ast.AppendFile, ast.InputFile, ast.RandomFile:
+		default:
+			if obj, ok := i.Val.(*Object); !ok || obj != nil {
+				return bi, fmt.Errorf("cannot encode %s literal", i.Typ)
+			}
+		}
+	case GlobalRef:
+		bi.Op, bi.Name, bi.Index = "GlobalRef", i.Name, i.Index
+	case GlobalVal:
+		bi.Op, bi.Name, bi.Index = "GlobalVal", i.Name, i.Index
+	case ParamRef:
+		bi.Op, bi.Name, bi.Index = "ParamRef", i.Name, i.Index
+	case ParamVal:
+		bi.Op, bi.Name, bi.Index = "ParamVal", i.Name, i.Index
+	case ParamPtr:
+		bi.Op, bi.Name, bi.Index = "ParamPtr", i.Name, i.Index
+	case LocalRef:
+		bi.Op, bi.Name, bi.Index = "LocalRef", i.Name, i.Index
+	case LocalVal:
+		bi.Op, bi.Name, bi.Index = "LocalVal", i.Name, i.Index
+	case Dup:
+		bi.Op, bi.N = "Dup", i.Skip
+	case Pop:
+		bi.Op = "Pop"
+	case Deref:
+		bi.Op = "Deref"
+	default:
+		return bi, fmt.Errorf("cannot encode %T", inst)
+	}
+	return bi, nil
+}
+
+// ReadBytecode loads an assembly written by [Assembly.WriteBytecode], returning it with the name
+// of the source file it was built from.
+func ReadBytecode(r io.Reader) (*Assembly, string, error) {
+	var f bcFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, "", fmt.Errorf("not a bytecode file: %w", err)
+	}
+	if f.Format != BytecodeFormat {
+		return nil, "", errors.New("not a bytecode file")
+	}
+	if f.Version != BytecodeVersion {
+		return nil, "", fmt.Errorf("bytecode version %d is not supported (expected %d); rebuild from source", f.Version, BytecodeVersion)
+	}
+
+	d := bcDecoder{
+		f:     &f,
+		types: typeTable{},
+	}
+	as, err := d.decode()
+	if err != nil {
+		return nil, "", fmt.Errorf("bad bytecode: %w", err)
+	}
+	return as, f.Source, nil
+}
+
+type bcDecoder struct {
+	f       *bcFile
+	types   typeTable
+	labels  []*Label
+	scopes  []*ast.Scope
+	vtables []Vtable
+}
+
+var builtinTypes = map[string]ast.Type{}
+
+func init() {
+	for _, typ := range []ast.Type{
+		ast.UnresolvedType, ast.Integer, ast.Real, ast.String, ast.Character, ast.Boolean,
+		ast.OutputFile, ast.AppendFile, ast.InputFile, ast.RandomFile,
+	} {
+		builtinTypes[string(typ.Key())] = typ
+	}
+}
+
+var operatorNames = map[string]ast.Operator{}
+
+func init() {
+	for op := ast.ADD; op <= ast.NEG; op++ {
+		operatorNames[op.Name()] = op
+	}
+}
+
+func (d *bcDecoder) decode() (*Assembly, error) {
+	f := d.f
+	if len(f.Scopes) == 0 || f.Scopes[0].Kind != "global" {
+		return nil, errors.New("missing global scope")
+	}
+
+	symbols := map[string]*Label{}
+	for _, bl := range f.Labels {
+		if bl.PC < 0 || bl.PC >= len(f.Code) {
+			return nil, fmt.Errorf("label %s: pc %d out of range", bl.Name, bl.PC)
+		}
+		lbl := &Label{Name: bl.Name, PC: bl.PC}
+		d.labels = append(d.labels, lbl)
+		if bl.Key != "" {
+			symbols[bl.Key] = lbl
+		}
+	}
+
+	// classes first, since any type may refer to them
+	global := ast.NewGlobalScope(&ast.Block{SourceInfo: f.Scopes[0].BodySrc.sourceInfo()})
+	for _, bc := range f.Classes {
+		if _, ok := d.types[bc.Name]; ok {
+			return nil, fmt.Errorf("duplicate class %s", bc.Name)
+		}
+		ct := &ast.ClassType{TypeKey: ast.TypeKey(bc.Name)}
+		cs := &ast.ClassStmt{
+			SourceInfo: bc.Src.sourceInfo(),
+			Name:       bc.Name,
+			Extends:    bc.Extends,
+			Type:       ct,
+			Block:      &ast.Block{SourceInfo: bc.BodySrc.sourceInfo()},
+		}
+		cs.Scope = ast.NewClassScope(cs, global)
+		ct.Class, ct.Scope = cs, cs.Scope
+		global.AddClass(cs)
+		d.types[bc.Name] = ct
+	}
+	d.vtables = make([]Vtable, len(f.Classes))
+	vtables := make([][]*Label, len(f.Classes))
+	for i, bc := range f.Classes {
+		ct := d.types[bc.Name].(*ast.ClassType)
+		if bc.Extends != "" {
+			ext, err := d.types.classType(bc.Extends)
+			if err != nil {
+				return nil, err
+			}
+			ct.Extends = ext
+		}
+		for _, bv := range bc.Fields {
+			vd, err := d.varDecl(bv)
+			if err != nil {
+				return nil, err
+			}
+			vd.Enclosing, vd.Scope, vd.Id = ct, ct.Scope, len(ct.Scope.Fields)
+			ct.Scope.Fields = append(ct.Scope.Fields, vd)
+		}
+		for _, index := range bc.Vtable {
+			lbl, err := d.label(index)
+			if err != nil {
+				return nil, err
+			}
+			vtables[i] = append(vtables[i], lbl)
+			d.vtables[i] = append(d.vtables[i], lbl.PC)
+		}
+	}
+
+	for i, bs := range f.Scopes {
+		parent := global
+		if i == 0 {
+			parent = nil
+		}
+		s, err := d.scope(bs, parent, global)
+		if err != nil {
+			return nil, fmt.Errorf("scope %d: %w", i, err)
+		}
+		d.scopes = append(d.scopes, s)
+	}
+
+	code := make([]Inst, len(f.Code))
+	for pc, bi := range f.Code {
+		inst, err := d.decodeInst(bi)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", PcRef(pc), bi.Op, err)
+		}
+		code[pc] = inst
+	}
+	// calls and vcalls find the callee's scope from its begin
+	for pc, inst := range code {
+		if call, ok := inst.(Call); ok {
+			if _, ok := code[call.Label.PC].(Begin); !ok {
+				return nil, fmt.Errorf("%s: call to %s: not a function", PcRef(pc), call.Label)
+			}
+		}
+	}
+	for _, vt := range d.vtables {
+		for _, pc := range vt {
+			if _, ok := code[pc].(Begin); !ok {
+				return nil, fmt.Errorf("vtable entry %s: not a function", PcRef(pc))
+			}
+		}
+	}
+
+	classes := make([]string, len(f.Classes))
+	for i, bc := range f.Classes {
+		classes[i] = bc.Name
+	}
+	return &Assembly{
+		GlobalScope: d.scopes[0],
+		Code:        code,
+		Labels:      symbols,
+		Strings:     f.Strings,
+		Classes:     classes,
+		Vtables:     vtables,
+	}, nil
+}
+
+func (d *bcDecoder) scope(bs bcScope, parent *ast.Scope, global *ast.Scope) (*ast.Scope, error) {
+	var enclosing *ast.ClassType
+	if bs.Class != "" {
+		ct, err := d.types.classType(bs.Class)
+		if err != nil {
+			return nil, err
+		}
+		enclosing, parent = ct, ct.Scope
+	}
+
+	var s *ast.Scope
+	body := &ast.Block{SourceInfo: bs.BodySrc.sourceInfo()}
+	switch bs.Kind {
+	case "global":
+		if parent != nil {
+			return nil, errors.New("extra global scope")
+		}
+		s = global
+	case "module":
+		ms := &ast.ModuleStmt{
+			SourceInfo:    bs.Src.sourceInfo(),
+			Name:          bs.Name,
+			Block:         body,
+			IsConstructor: bs.Constructor,
+			Enclosing:     enclosing,
+		}
+		s = ast.NewModuleScope(ms, parent)
+		ms.Scope = s
+	case "function":
+		typ, err := d.types.lookup(bs.Type)
+		if err != nil {
+			return nil, err
+		}
+		fs := &ast.FunctionStmt{
+			SourceInfo: bs.Src.sourceInfo(),
+			Name:       bs.Name,
+			Type:       typ,
+			Block:      body,
+			Enclosing:  enclosing,
+		}
+		s = ast.NewFunctionScope(fs, parent)
+		fs.Scope = s
+	default:
+		return nil, fmt.Errorf("unknown scope kind %q", bs.Kind)
+	}
+
+	for _, bv := range bs.Params {
+		vd, err := d.varDecl(bv)
+		if err != nil {
+			return nil, err
+		}
+		vd.IsParam, vd.Scope, vd.Id = true, s, len(s.Params)
+		s.Params = append(s.Params, vd)
+	}
+	for _, bv := range bs.Locals {
+		vd, err := d.varDecl(bv)
+		if err != nil {
+			return nil, err
+		}
+		vd.Scope, vd.Id = s, len(s.Locals)
+		s.Locals = append(s.Locals, vd)
+	}
+	if s.ModuleStmt != nil {
+		s.ModuleStmt.Params = s.Params
+	} else if s.FunctionStmt != nil {
+		s.FunctionStmt.Params = s.Params
+	}
+	return s, nil
+}
+
+func (d *bcDecoder) varDecl(bv bcVar) (*ast.VarDecl, error) {
+	typ, err := d.types.lookup(bv.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.VarDecl{
+		SourceInfo: bv.Src.sourceInfo(),
+		Name:       bv.Name,
+		Type:       typ,
+		IsConst:    bv.Const,
+		IsRef:      bv.Ref,
+	}, nil
+}
+
+// typeTable resolves type keys to the classes of a program being loaded, interning array types so
+// they compare by identity.
+type typeTable map[string]ast.Type
+
+func (tt typeTable) lookup(key string) (ast.Type, error) {
+	if typ, ok := builtinTypes[key]; ok {
+		return typ, nil
+	}
+	if typ, ok := tt[key]; ok {
+		return typ, nil
+	}
+	if elemKey, ok := strings.CutSuffix(key, "[]"); ok {
+		elem, err := tt.lookup(elemKey)
+		if err != nil {
+			return nil, err
+		}
+		base, nDims := elem, 1
+		if elem.IsArrayType() {
+			at := elem.AsArrayType()
+			base, nDims = at.Base, at.NDims+1
+		}
+		typ := &ast.ArrayType{
+			Base:        base,
+			NDims:       nDims,
+			ElementType: elem,
+			TypeKey:     ast.TypeKey(key),
+		}
+		tt[key] = typ
+		return typ, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", key)
+}
+
+func (tt typeTable) arrayType(key string) (*ast.ArrayType, error) {
+	typ, err := tt.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	if !typ.IsArrayType() {
+		return nil, fmt.Errorf("%s is not an array type", key)
+	}
+	return typ.AsArrayType(), nil
+}
+
+func (tt typeTable) classType(name string) (*ast.ClassType, error) {
+	typ, ok := tt[name]
+	if !ok || !typ.IsClassType() {
+		return nil, fmt.Errorf("unknown class %q", name)
+	}
+	return typ.AsClassType(), nil
+}
+
+func (d *bcDecoder) label(index int) (*Label, error) {
+	if index < 0 || index >= len(d.labels) {
+		return nil, fmt.Errorf("label %d out of range", index)
+	}
+	return d.labels[index], nil
+}
+
+func lookupOperator(name string) (ast.Operator, error) {
+	op, ok := operatorNames[name]
+	if !ok {
+		return ast.INVALID_OPERATOR, fmt.Errorf("unknown operator %q", name)
+	}
+	return op, nil
+}
+
+func (d *bcDecoder) decodeInst(bi bcInst) (Inst, error) {
+	si := bi.Src.sourceInfo()
+	switch bi.Op {
+	case "ArrayRef", "ArrayVal":
+		ot := OffsetType(bi.Index)
+		if ot != OffsetTypeString && ot != OffsetTypeArray || bi.Op == "ArrayRef" && ot != OffsetTypeArray {
+			return nil, fmt.Errorf("bad offset type %d", bi.Index)
+		}
+		if bi.Op == "ArrayRef" {
+			return ArrayRef{SourceInfo: si, OffsetType: ot}, nil
+		}
+		return ArrayVal{SourceInfo: si, OffsetType: ot}, nil
+	case "ArrayNew":
+		at, err := d.types.arrayType(bi.Type)
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayNew{SourceInfo: si, Typ: at, Size: bi.N}, nil
+	case "ArrayClone":
+		at, err := d.types.arrayType(bi.Type)
+		if err != nil {
+			return nil, err
+		}
+		return ArrayClone{SourceInfo: si, Typ: at, NDims: bi.N}, nil
+	case "ArrayLen":
+		return ArrayLen{SourceInfo: si}, nil
+	case "Store":
+		return Store{SourceInfo: si}, nil
+	case "BinOpInt", "BinOpReal", "BinOpStr", "BinOpChar", "BinOpBool", "UnaryOpInt", "UnaryOpFloat", "UnaryOpBool":
+		op, err := lookupOperator(bi.Val)
+		if err != nil {
+			return nil, err
+		}
+		switch bi.Op {
+		case "BinOpInt":
+			return BinOpInt{SourceInfo: si, Op: op}, nil
+		case "BinOpReal":
+			return BinOpReal{SourceInfo: si, Op: op}, nil
+		case "BinOpStr":
+			return BinOpStr{SourceInfo: si, Op: op}, nil
+		case "BinOpChar":
+			return BinOpChar{SourceInfo: si, Op: op}, nil
+		case "BinOpBool":
+			return BinOpBool{SourceInfo: si, Op: op}, nil
+		case "UnaryOpInt":
+			return UnaryOpInt{SourceInfo: si, Op: op}, nil
+		case "UnaryOpFloat":
+			return UnaryOpFloat{SourceInfo: si, Op: op}, nil
+		default:
+			return UnaryOpBool{SourceInfo: si, Op: op}, nil
+		}
+	case "IncrInt":
+		val, err := strconv.ParseInt(bi.Val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return IncrInt{SourceInfo: si, Val: val}, nil
+	case "IncrReal":
+		val, err := strconv.ParseFloat(bi.Val, 64)
+		if err != nil {
+			return nil, err
+		}
+		return IncrReal{SourceInfo: si, Val: val}, nil
+	case "ObjNew":
+		ct, err := d.types.classType(bi.Class)
+		if err != nil {
+			return nil, err
+		}
+		return ObjNew{SourceInfo: si, Type: ct, Vtable: &d.vtables[ct.Class.Id], NFields: bi.N}, nil
+	case "FieldRef":
+		return FieldRef{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "FieldVal":
+		return FieldVal{SourceInfo: si, Class: bi.Class, Name: bi.Name, Index: bi.Index}, nil
+	case "VCall":
+		return VCall{SourceInfo: si, Class: bi.Class, Name: bi.Name, Index: bi.Index, NArgs: bi.N}, nil
+	case "Begin":
+		lbl, err := d.label(bi.Label)
+		if err != nil {
+			return nil, err
+		}
+		if bi.Scope < 0 || bi.Scope >= len(d.scopes) {
+			return nil, fmt.Errorf("scope %d out of range", bi.Scope)
+		}
+		return Begin{SourceInfo: si, Scope: d.scopes[bi.Scope], Label: lbl, NParams: bi.N, NLocals: bi.M}, nil
+	case "End", "Jump", "JumpFalse", "JumpTrue", "Call":
+		lbl, err := d.label(bi.Label)
+		if err != nil {
+			return nil, err
+		}
+		switch bi.Op {
+		case "End":
+			return End{SourceInfo: si, Label: lbl}, nil
+		case "Jump":
+			return Jump{SourceInfo: si, Label: lbl}, nil
+		case "JumpFalse":
+			return JumpFalse{SourceInfo: si, Label: lbl}, nil
+		case "JumpTrue":
+			return JumpTrue{SourceInfo: si, Label: lbl}, nil
+		default:
+			return Call{SourceInfo: si, Label: lbl, NArgs: bi.N}, nil
+		}
+	case "Halt":
+		return Halt{SourceInfo: si, NVal: bi.N, Exit: bi.Exit}, nil
+	case "IntToReal":
+		return IntToReal{SourceInfo: si}, nil
+	case "RealToInt":
+		return RealToInt{SourceInfo: si}, nil
+	case "CharToString":
+		return CharToString{SourceInfo: si}, nil
+	case "Return":
+		return Return{SourceInfo: si, NVal: bi.N}, nil
+	case "LibCall":
+		typ, err := d.types.lookup(bi.Type)
+		if err != nil {
+			return nil, err
+		}
+		// look the function up by name, in case the library has changed since the build
+		index, ok := lib.LookupIndex(bi.Val)
+		if !ok {
+			return nil, fmt.Errorf("unknown library function %q", bi.Val)
+		}
+		return LibCall{SourceInfo: si, Name: bi.Name, Type: typ, Index: index, NArg: bi.N}, nil
+	case "Literal":
+		return d.literal(si, bi)
+	case "GlobalRef":
+		return GlobalRef{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "GlobalVal":
+		return GlobalVal{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "ParamRef":
+		return ParamRef{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "ParamVal":
+		return ParamVal{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "ParamPtr":
+		return ParamPtr{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "LocalRef":
+		return LocalRef{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "LocalVal":
+		return LocalVal{SourceInfo: si, Name: bi.Name, Index: bi.Index}, nil
+	case "Dup":
+		return Dup{SourceInfo: si, Skip: bi.N}, nil
+	case "Pop":
+		return Pop{SourceInfo: si}, nil
+	case "Deref":
+		return Deref{SourceInfo: si}, nil
+	default:
+		return nil, errors.New("unknown op")
+	}
+}
+
+func (d *bcDecoder) literal(si ast.SourceInfo, bi bcInst) (Inst, error) {
+	typ, err := d.types.lookup(bi.Type)
+	if err != nil {
+		return nil, err
+	}
+	lit := Literal{SourceInfo: si, Typ: typ}
+	switch typ {
+	case ast.Integer:
+		lit.Val, err = strconv.ParseInt(bi.Val, 10, 64)
+	case ast.Real:
+		lit.Val, err = strconv.ParseFloat(bi.Val, 64)
+	case ast.String:
+		if bi.Tab {
+			lit.Val = lib.TabDisplay
+		} else {
+			lit.Val, lit.Id = bi.Val, bi.Index
+		}
+	case ast.Character:
+		r := []rune(bi.Val)
+		if len(r) != 1 {
+			err = fmt.Errorf("bad character %q", bi.Val)
+		} else {
+			lit.Val = r[0]
+		}
+	case ast.Boolean:
+		lit.Val, err = strconv.ParseBool(bi.Val)
+	case ast.OutputFile, ast.AppendFile, ast.InputFile, ast.RandomFile:
+		lit.Val = ZeroValue(typ)
+	default:
+		if typ.IsClassType() {
+			lit.Val = ZeroValue(typ)
+			break
+		}
+		err = fmt.Errorf("cannot decode %s literal", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lit, nil
+}
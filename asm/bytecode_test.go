@@ -0,0 +1,69 @@
+package asm
+
+import (
+	"bytes"
+	"github.com/dragonsinth/gaddis/ast"
+	"strings"
+	"testing"
+)
+
+func TestBytecode(t *testing.T) {
+	global := ast.NewGlobalScope(&ast.Block{})
+	fs := &ast.FunctionStmt{Name: "double", Type: ast.Integer, Block: &ast.Block{}}
+	fs.Scope = ast.NewFunctionScope(fs, global)
+	fs.Scope.AddVariable(&ast.VarDecl{Name: "n", Type: ast.Integer, IsParam: true})
+
+	globalLabel := &Label{Name: "global$", PC: 0}
+	fnLabel := &Label{Name: "double", PC: 4}
+	as := &Assembly{
+		GlobalScope: global,
+		Code: []Inst{
+			Begin{Scope: global, Label: globalLabel},
+			Literal{Typ: ast.Integer, Val: int64(21)},
+			Call{Label: fnLabel, NArgs: 1},
+			Halt{NVal: 1, Exit: true},
+			Begin{Scope: fs.Scope, Label: fnLabel, NParams: 1},
+			ParamVal{Name: "n", Index: 0},
+			Literal{Typ: ast.Integer, Val: int64(2)},
+			BinOpInt{Op: ast.MUL},
+			Return{NVal: 1},
+			End{Label: fnLabel},
+		},
+		Labels: map[string]*Label{"double": fnLabel},
+	}
+
+	var buf bytes.Buffer
+	if err := as.WriteBytecode(&buf, "double.gad"); err != nil {
+		t.Fatal(err)
+	}
+	got, filename, err := ReadBytecode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename != "double.gad" {
+		t.Errorf("got filename %q", filename)
+	}
+	if got.Labels["double"].PC != 4 {
+		t.Errorf("got labels %v", got.Labels)
+	}
+	if scope := got.Code[4].(Begin).Scope; scope.String() != "Function Integer double" || len(scope.Params) != 1 {
+		t.Errorf("got scope %s with params %v", scope, scope.Params)
+	}
+
+	p := got.NewExecution(&ExecutionContext{})
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if p.ExitCode != 42 {
+		t.Errorf("got exit code %d, want 42", p.ExitCode)
+	}
+
+	// other versions are rejected
+	old := strings.Replace(buf.String(), `"version":1`, `"version":0`, 1)
+	if _, _, err := ReadBytecode(strings.NewReader(old)); err == nil || !strings.Contains(err.Error(), "version 0") {
+		t.Errorf("expected a version error, got %v", err)
+	}
+	if _, _, err := ReadBytecode(strings.NewReader("Display 1\n")); err == nil {
+		t.Error("expected an error reading source as bytecode")
+	}
+}
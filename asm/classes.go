@@ -3,6 +3,7 @@ package asm
 import (
 	"fmt"
 	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
 	"slices"
 )
 
@@ -75,7 +76,7 @@ func (i FieldVal) Exec(p *Execution) {
 	ref := p.Pop().(*Object)
 	val := ref.Fields[i.Index]
 	if val == nil {
-		panic(fmt.Sprintf("field %s read before assignment", i.Name)) // TODO: zero-init classes
+		panic(lib.Errorf(lib.Uninitialized, "field %s read before assignment", i.Name)) // TODO: zero-init classes
 	}
 	p.Push(val)
 }
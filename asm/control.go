@@ -55,22 +55,28 @@ func (i End) Sym() string {
 }
 
 // Halt terminates the program with an expected number of values left on the eval stack.
-// Used for expression evaluation.
+// Used for expression evaluation, and for exit, where the value is the exit code.
 type Halt struct {
 	baseInst
 	ast.SourceInfo
 	NVal int
+	Exit bool
 }
 
 func (i Halt) Exec(p *Execution) {
 	if len(p.Frame.Eval) != i.NVal {
 		panic(p.Frame.Eval)
 	}
+	if i.Exit {
+		p.ExitCode = int(p.Frame.Eval[0].(int64))
+	}
 	p.Frame = nil
 }
 
 func (i Halt) String() string {
-	if i.NVal == 0 {
+	if i.Exit {
+		return "exit"
+	} else if i.NVal == 0 {
 		return "halt"
 	} else {
 		return fmt.Sprintf("halt(%d)", i.NVal)
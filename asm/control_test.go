@@ -0,0 +1,22 @@
+package asm
+
+import (
+	"testing"
+)
+
+func TestHaltExit(t *testing.T) {
+	p := &Execution{
+		Stack: []Frame{{}, {}},
+	}
+	p.Frame = &p.Stack[1]
+
+	// exit from a nested frame
+	p.Push(int64(3))
+	Halt{NVal: 1, Exit: true}.Exec(p)
+	if p.Frame != nil {
+		t.Error("expected program to halt")
+	}
+	if p.ExitCode != 3 {
+		t.Errorf("got exit code %d, want 3", p.ExitCode)
+	}
+}
@@ -37,7 +37,7 @@ type CharToString struct {
 
 func (i CharToString) Exec(p *Execution) {
 	tip := len(p.Frame.Eval) - 1
-	p.Frame.Eval[tip] = string(p.Frame.Eval[tip].(byte))
+	p.Frame.Eval[tip] = string(p.Frame.Eval[tip].(rune))
 }
 
 func (i CharToString) String() string {
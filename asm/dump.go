@@ -3,6 +3,7 @@ package asm
 import (
 	"bytes"
 	"fmt"
+	"github.com/dragonsinth/gaddis/ast"
 	"strconv"
 	"strings"
 )
@@ -36,30 +37,39 @@ func (as *Assembly) Dump(source string) string {
 			sb.WriteRune('-')
 			sb.WriteString(PcRef(endPcs[0]))
 			sb.WriteRune(':')
+			if fs := be.Scope.FunctionStmt; fs != nil {
+				sb.WriteString(fs.Type.String())
+				sb.WriteRune(' ')
+			}
 			sb.WriteString(be.Label.Name)
 			endPcs = endPcs[1:]
 			sb.WriteRune('(')
-			for i, vd := range be.Scope.Params {
-				if i > 0 {
-					sb.WriteRune('|')
-				}
-				sb.WriteString(vd.Type.String())
-				sb.WriteRune('#')
-				sb.WriteString(vd.Name)
-			}
+			dumpVars(&sb, be.Scope.Params)
 			sb.WriteRune(')')
-			for i, vd := range be.Scope.Locals {
-				if i > 0 {
-					sb.WriteRune('|')
-				}
-				sb.WriteString(vd.Type.String())
-				sb.WriteRune('#')
-				sb.WriteString(vd.Name)
-			}
+			dumpVars(&sb, be.Scope.Locals)
 			sb.WriteRune('\n')
 		}
 	}
 
+	// Dump the classes, with their fields and vtables
+	if len(as.Classes) > 0 {
+		sb.WriteString("; -- classes\n")
+	}
+	for i, name := range as.Classes {
+		_, _ = fmt.Fprintf(&sb, ";[%d] %s(", i, name)
+		dumpVars(&sb, as.GlobalScope.Classes[i].Scope.Fields)
+		sb.WriteRune(')')
+		for j, lbl := range as.Vtables[i] {
+			if j == 0 {
+				sb.WriteRune(' ')
+			} else {
+				sb.WriteRune('|')
+			}
+			sb.WriteString(lbl.String())
+		}
+		sb.WriteRune('\n')
+	}
+
 	// Dump the string table
 	sb.WriteString("; -- strings\n")
 	for i, str := range as.Strings {
@@ -68,6 +78,20 @@ func (as *Assembly) Dump(source string) string {
 	return sb.String()
 }
 
+func dumpVars(sb *bytes.Buffer, vars []*ast.VarDecl) {
+	for i, vd := range vars {
+		if i > 0 {
+			sb.WriteRune('|')
+		}
+		sb.WriteString(vd.Type.String())
+		if vd.IsRef {
+			sb.WriteString(" Ref")
+		}
+		sb.WriteRune('#')
+		sb.WriteString(vd.Name)
+	}
+}
+
 func PcRef(pc int) string {
 	pc = pc * 4
 	pc += 0x1000 // start program here
@@ -0,0 +1,126 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrorKind classifies a RuntimeError, so it can be explained to the user.
+type ErrorKind = lib.ErrorKind
+
+const (
+	GenericError      = lib.GenericError
+	IndexOutOfRange   = lib.IndexOutOfRange
+	DivisionByZero    = lib.DivisionByZero
+	BadConversion     = lib.BadConversion
+	FileNotOpen       = lib.FileNotOpen
+	Uninitialized     = lib.Uninitialized
+	EndOfFile         = lib.EndOfFile
+	Overflow          = lib.Overflow
+	ArraySizeMismatch = lib.ArraySizeMismatch
+)
+
+// RuntimeError is a failure executing a program, attributed to the instruction that failed.
+type RuntimeError struct {
+	Kind ErrorKind
+	ast.SourceInfo
+	Err error // the underlying error
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Render formats the error with the offending source line, a caret under the failing expression,
+// and a hint.
+func (e *RuntimeError) Render(filename string, src string) string {
+	var sb strings.Builder
+	start, end := e.Start, e.End
+	_, _ = fmt.Fprintf(&sb, "%s:%d:%d: %s\n", filename, start.Line+1, start.Column+1, e.Err)
+
+	lines := strings.Split(src, "\n")
+	if start.Line < len(lines) {
+		line := []rune(strings.TrimRight(lines[start.Line], "\r"))
+		gutter := strconv.Itoa(start.Line + 1)
+		_, _ = fmt.Fprintf(&sb, " %s | %s\n", gutter, string(line))
+
+		if end.Line != start.Line || end.Column > len(line) {
+			end.Column = len(line)
+		}
+		if start.Column <= len(line) {
+			var caret strings.Builder
+			for _, r := range line[:start.Column] {
+				if r == '\t' {
+					caret.WriteRune('\t') // keep the caret aligned under tabs
+				} else {
+					caret.WriteRune(' ')
+				}
+			}
+			caret.WriteString(strings.Repeat("^", max(1, end.Column-start.Column)))
+			_, _ = fmt.Fprintf(&sb, " %s | %s\n", strings.Repeat(" ", len(gutter)), caret.String())
+		}
+	}
+	if hint := e.Kind.Hint(); hint != "" {
+		_, _ = fmt.Fprintf(&sb, "hint: %s\n", hint)
+	}
+	return sb.String()
+}
+
+// NewRuntimeError converts a recovered panic into a RuntimeError for the current instruction;
+// call it before AddPanicFrames.
+func (p *Execution) NewRuntimeError(r any) *RuntimeError {
+	if re, ok := r.(*RuntimeError); ok {
+		return re
+	}
+	var err error
+	if isErr, ok := r.(error); ok {
+		err = isErr
+	} else {
+		err = errors.New(fmt.Sprint(r))
+	}
+	kind, err := classifyError(err)
+	return &RuntimeError{
+		Kind:       kind,
+		SourceInfo: p.Code[p.PC].GetSourceInfo(),
+		Err:        err,
+	}
+}
+
+// classifyError determines the kind of err, rewording raw Go errors into Gaddis terms.
+func classifyError(err error) (ErrorKind, error) {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		typ := "Integer"
+		if numErr.Func == "ParseFloat" {
+			typ = "Real"
+		}
+		if errors.Is(numErr.Err, strconv.ErrRange) {
+			return BadConversion, fmt.Errorf("%q is out of range for %s", numErr.Num, typ)
+		}
+		return BadConversion, fmt.Errorf("cannot convert %q to %s", numErr.Num, typ)
+	}
+	var arithErr ast.ArithmeticError
+	if errors.As(err, &arithErr) {
+		if arithErr.DivByZero {
+			return DivisionByZero, err
+		}
+		return Overflow, err
+	}
+	var libErr lib.RuntimeError
+	if errors.As(err, &libErr) {
+		return libErr.Kind, err
+	}
+	if errors.Is(err, io.EOF) {
+		return EndOfFile, err
+	}
+	return GenericError, err
+}
@@ -0,0 +1,82 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	_, numErr := strconv.ParseInt("abc", 10, 64)
+
+	for _, tc := range []struct {
+		err  error
+		kind ErrorKind
+		msg  string
+	}{
+		{lib.Errorf(lib.IndexOutOfRange, "index 3 out of range for array of size 3"), IndexOutOfRange, "index 3 out of range for array of size 3"},
+		{ast.ArithmeticError{DivByZero: true, Msg: "integer division by zero: 1 / 0"}, DivisionByZero, "integer division by zero: 1 / 0"},
+		{numErr, BadConversion, `cannot convert "abc" to Integer`},
+		{lib.Errorf(lib.FileNotOpen, "file not open"), FileNotOpen, "file not open"},
+		{lib.Errorf(lib.ArraySizeMismatch, "array size mismatch: [2] not assignable to [5]"), ArraySizeMismatch, "array size mismatch: [2] not assignable to [5]"},
+		{lib.Errorf(lib.Uninitialized, "local n read before assignment"), Uninitialized, "local n read before assignment"},
+		{fmt.Errorf("data.txt: %w", io.EOF), EndOfFile, "data.txt: EOF"},
+		{fmt.Errorf("wrapped: %w", lib.Errorf(lib.EndOfFile, "data.txt: end of file reading record 2")), EndOfFile, "wrapped: data.txt: end of file reading record 2"},
+		// the kind comes from the type, not the message
+		{errors.New("compiler bug!?: param n read before assignment"), GenericError, "compiler bug!?: param n read before assignment"},
+		{errors.New("abs: result of abs(-9223372036854775808) is out of range"), GenericError, "abs: result of abs(-9223372036854775808) is out of range"},
+	} {
+		kind, err := classifyError(tc.err)
+		if kind != tc.kind || err.Error() != tc.msg {
+			t.Errorf("%v: got %s %q, want %s %q", tc.err, kind, err, tc.kind, tc.msg)
+		}
+	}
+}
+
+func TestVMErrorKinds(t *testing.T) {
+	global := ast.NewGlobalScope(&ast.Block{})
+	lbl := &Label{Name: "global$", PC: 0}
+	for _, tc := range []struct {
+		name string
+		code []Inst
+		kind ErrorKind
+		msg  string
+	}{
+		{"underflow", []Inst{BinOpInt{Op: ast.ADD}}, GenericError, "eval stack underflow"},
+		{"index", []Inst{&ArrayNew{Typ: &ast.ArrayType{ElementType: ast.Integer, NDims: 1}, Size: 0}, Literal{Typ: ast.Integer, Val: int64(-1)}, ArrayVal{OffsetType: OffsetTypeArray}}, IndexOutOfRange, "index -1 out of range for array of size 0"},
+		{"uninitialized", []Inst{LocalVal{Name: "n", Index: 0}}, Uninitialized, "local n read before assignment"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code := append([]Inst{Begin{Scope: global, Label: lbl, NLocals: 1}}, tc.code...)
+			as := &Assembly{GlobalScope: global, Code: append(code, End{Label: lbl})}
+			err := as.NewExecution(&ExecutionContext{}).Run()
+			var re *RuntimeError
+			if !errors.As(err, &re) || re.Kind != tc.kind || re.Error() != tc.msg {
+				t.Errorf("got %#v, want %s %q", err, tc.kind, tc.msg)
+			}
+		})
+	}
+}
+
+func TestRenderRuntimeError(t *testing.T) {
+	src := "Declare Integer x = 0\n\tDisplay \"Zoë\", 10 / x\n"
+	re := &RuntimeError{
+		Kind: DivisionByZero,
+		SourceInfo: ast.SourceInfo{
+			Start: ast.Position{Line: 1, Column: 16},
+			End:   ast.Position{Line: 1, Column: 22},
+		},
+		Err: errors.New("integer division by zero"),
+	}
+	want := "test.gad:2:17: integer division by zero\n" +
+		" 2 | \tDisplay \"Zoë\", 10 / x\n" +
+		"   | \t               ^^^^^^\n" +
+		"hint: " + DivisionByZero.Hint() + "\n"
+	if got := re.Render("test.gad", src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
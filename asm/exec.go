@@ -1,7 +1,6 @@
 package asm
 
 import (
-	"errors"
 	"fmt"
 	"github.com/dragonsinth/gaddis/ast"
 	"github.com/dragonsinth/gaddis/lib"
@@ -16,12 +15,23 @@ const MaxInstructions = 1 << 30
 const MaxStack = 1024
 
 type ExecutionContext struct {
-	Rng *rand.Rand
+	Rng   *rand.Rand
+	Clock lib.Clock // defaults to the system clock
+	Args  []string  // the program's command-line arguments
 	lib.IoProvider
+
+	MaxInstructions int // if non-zero, a lower limit on instructions executed than [MaxInstructions]
+
+	// Hook, if set, is called before each instruction executes; used for profiling and tracing.
+	Hook func(p *Execution, inst Inst)
 }
 
 func (as *Assembly) NewExecution(ec *ExecutionContext) *Execution {
-	extlib := lib.CreateLibrary(ec.IoProvider, lib.RandContext{Rng: ec.Rng})
+	clock := ec.Clock
+	if clock == nil {
+		clock = lib.SystemClock
+	}
+	extlib := lib.CreateLibrary(ec.IoProvider, lib.RandContext{Rng: ec.Rng}, lib.NewTimeContext(clock), lib.ProgramContext{Args: ec.Args})
 
 	p := &Execution{
 		PC:   0,
@@ -34,17 +44,27 @@ func (as *Assembly) NewExecution(ec *ExecutionContext) *Execution {
 		}},
 		Frame: nil,
 		Lib:   extlib,
+
+		maxInstructions: MaxInstructions,
+		hook:            ec.Hook,
+	}
+	if ec.MaxInstructions > 0 {
+		p.maxInstructions = min(ec.MaxInstructions, MaxInstructions)
 	}
 	p.Frame = &p.Stack[0]
 	return p
 }
 
 type Execution struct {
-	PC    int
-	Code  []Inst
-	Stack []Frame
-	Frame *Frame
-	Lib   []lib.Func
+	PC       int
+	Code     []Inst
+	Stack    []Frame
+	Frame    *Frame
+	Lib      []lib.Func
+	ExitCode int // set by an exit halt
+
+	maxInstructions int
+	hook            func(p *Execution, inst Inst)
 }
 
 type Frame struct {
@@ -69,11 +89,7 @@ type NativeFrame struct {
 func (p *Execution) Run() (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			if isErr, ok := r.(error); ok {
-				err = isErr
-			} else {
-				err = errors.New(fmt.Sprint(r))
-			}
+			err = p.NewRuntimeError(r)
 			p.AddPanicFrames()
 		}
 	}()
@@ -81,11 +97,14 @@ func (p *Execution) Run() (err error) {
 	instructionCount := 0
 	for p.Frame != nil {
 		inst := p.Code[p.PC]
+		if p.hook != nil {
+			p.hook(p, inst)
+		}
 		inst.Exec(p)
 		p.PC++
 
 		instructionCount++
-		if instructionCount > MaxInstructions {
+		if instructionCount > p.maxInstructions {
 			panic("infinite loop detected")
 		}
 	}
@@ -195,6 +214,9 @@ func (p *Execution) Push(val any) {
 
 func (p *Execution) Pop() any {
 	tip := len(p.Frame.Eval) - 1
+	if tip < 0 {
+		panic("eval stack underflow")
+	}
 	ret := p.Frame.Eval[tip]
 	p.Frame.Eval = p.Frame.Eval[:tip]
 	return ret
@@ -202,6 +224,9 @@ func (p *Execution) Pop() any {
 
 func (p *Execution) PopN(n int) []any {
 	tip := len(p.Frame.Eval) - n
+	if tip < 0 {
+		panic("eval stack underflow")
+	}
 	ret := p.Frame.Eval[tip:]
 	p.Frame.Eval = p.Frame.Eval[:tip]
 	return ret
@@ -227,8 +252,8 @@ func DebugStringVal(typ ast.Type, arg any) string {
 		panic(typedArg) // should be impossible
 	case string:
 		return fmt.Sprintf("%#v", typedArg)
-	case byte:
-		return fmt.Sprintf("%#v", rune(typedArg))
+	case rune:
+		return fmt.Sprintf("%#v", typedArg)
 	case []any:
 		if typ == ast.UnresolvedType {
 			return "<array>"
@@ -268,7 +293,7 @@ func ZeroValue(typ ast.Type) any {
 	case ast.String:
 		return ""
 	case ast.Character:
-		return byte(0)
+		return rune(0)
 	case ast.Boolean:
 		return false
 	case ast.OutputFile:
@@ -277,6 +302,8 @@ func ZeroValue(typ ast.Type) any {
 		return lib.AppendFile{}
 	case ast.InputFile:
 		return lib.InputFile{}
+	case ast.RandomFile:
+		return lib.RandomFile{}
 	default:
 		if typ.IsClassType() {
 			return (*Object)(nil)
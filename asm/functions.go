@@ -78,11 +78,12 @@ type LibCall struct {
 func (i LibCall) Exec(p *Execution) {
 	args := p.PopN(i.NArg)
 	fn := p.Lib[i.Index].FuncPtr
+	fnType := fn.Type()
 	var ret []reflect.Value
-	if fn.Type().IsVariadic() {
+	if fnType.IsVariadic() {
 		var rArgs []reflect.Value
-		for i, c := 0, fn.Type().NumIn()-1; i < c; i++ {
-			rArgs = append(rArgs, reflect.ValueOf(args[0]))
+		for i, c := 0, fnType.NumIn()-1; i < c; i++ {
+			rArgs = append(rArgs, toNative(args[0], fnType.In(i)))
 			args = args[1:]
 		}
 		rArgs = append(rArgs, reflect.ValueOf(args))
@@ -90,20 +91,58 @@ func (i LibCall) Exec(p *Execution) {
 	} else {
 		rArgs := make([]reflect.Value, i.NArg)
 		for i, arg := range args {
-			rArgs[i] = reflect.ValueOf(arg)
+			rArgs[i] = toNative(arg, fnType.In(i))
 		}
 		ret = fn.Call(rArgs)
+		// copy back any reference params
+		for i, arg := range args {
+			if fnType.In(i).Kind() == reflect.Ptr {
+				*arg.(*any) = fromNative(rArgs[i].Elem())
+			}
+		}
 	}
 
 	switch len(ret) {
 	case 0:
 	case 1:
-		p.Push(ret[0].Interface())
+		p.Push(fromNative(ret[0]))
 	default:
 		panic(ret)
 	}
 }
 
+// toNative converts a VM value into the given native parameter type.
+// Arrays are converted into typed slices, and references into typed pointers.
+func toNative(val any, typ reflect.Type) reflect.Value {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(toNative(*val.(*any), typ.Elem()))
+		return ptr
+	case reflect.Slice:
+		arr := val.([]any)
+		ret := reflect.MakeSlice(typ, len(arr), len(arr))
+		for i, v := range arr {
+			ret.Index(i).Set(toNative(v, typ.Elem()))
+		}
+		return ret
+	default:
+		return reflect.ValueOf(val)
+	}
+}
+
+// fromNative converts a native value back into a VM value; typed slices become arrays.
+func fromNative(val reflect.Value) any {
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Interface {
+		ret := make([]any, val.Len())
+		for i := range ret {
+			ret[i] = fromNative(val.Index(i))
+		}
+		return ret
+	}
+	return val.Interface()
+}
+
 func (i LibCall) String() string {
 	return fmt.Sprintf("libcall(%d) %d:%s", i.NArg, i.Index, i.Name)
 }
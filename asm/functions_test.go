@@ -0,0 +1,51 @@
+package asm
+
+import (
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"reflect"
+	"testing"
+)
+
+func TestLibCallArrays(t *testing.T) {
+	p := &Execution{
+		Stack: []Frame{{}},
+		Lib: []lib.Func{
+			{Name: "rowSums", FuncPtr: reflect.ValueOf(func(grid [][]int64) []int64 {
+				var ret []int64
+				for _, row := range grid {
+					var sum int64
+					for _, v := range row {
+						sum += v
+					}
+					ret = append(ret, sum)
+				}
+				return ret
+			})},
+			{Name: "scale", FuncPtr: reflect.ValueOf(func(arr *[]float64, by float64) {
+				for i := range *arr {
+					(*arr)[i] *= by
+				}
+			})},
+		},
+	}
+	p.Frame = &p.Stack[0]
+
+	grid := []any{[]any{int64(1), int64(2)}, []any{int64(3), int64(4)}}
+	p.Push(grid)
+	LibCall{Name: "rowSums", Type: ast.UnresolvedType, Index: 0, NArg: 1}.Exec(p)
+	if got, want := p.Pop(), []any{int64(3), int64(7)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	var arr any = []any{1.0, 2.5}
+	p.Push(&arr)
+	p.Push(2.0)
+	LibCall{Name: "scale", Type: ast.UnresolvedType, Index: 1, NArg: 2}.Exec(p)
+	if want := []any{2.0, 5.0}; !reflect.DeepEqual(arr, want) {
+		t.Errorf("got %v, want %v", arr, want)
+	}
+	if len(p.Frame.Eval) != 0 {
+		t.Errorf("expected empty eval stack, got %v", p.Frame.Eval)
+	}
+}
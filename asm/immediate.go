@@ -39,7 +39,7 @@ func (i Literal) String() string {
 		}
 	case ast.Character:
 		typ = "chr"
-		str = strconv.QuoteRune(rune(i.Val.(byte)))
+		str = strconv.QuoteRune(i.Val.(rune))
 	case ast.Boolean:
 		typ = "bool"
 		if i.Val.(bool) {
@@ -56,6 +56,13 @@ func (i Literal) String() string {
 	case ast.InputFile:
 		typ = "in_file"
 		str = "{}"
+	case ast.RandomFile:
+		typ = "rnd_file"
+		str = "{}"
+	default:
+		// the only class literal is the zero value
+		typ = i.Typ.String()
+		str = "nil"
 	}
 	return fmt.Sprintf("literal %s %s", typ, str)
 }
@@ -70,6 +77,18 @@ var litTypes = []string{
 	ast.OutputFile:     "out_file",
 	ast.AppendFile:     "app_file",
 	ast.InputFile:      "in_file",
+	ast.RandomFile:     "rnd_file",
+}
+
+// litTypeName names a primitive or file type as instructions do, or a class by its name.
+func litTypeName(typ ast.Type) string {
+	if typ.IsClassType() {
+		return typ.String()
+	}
+	if typ.IsFileType() {
+		return litTypes[typ.AsFileType()]
+	}
+	return litTypes[typ.AsPrimitive()]
 }
 
 type GlobalRef struct {
@@ -99,7 +118,7 @@ type GlobalVal struct {
 func (i GlobalVal) Exec(p *Execution) {
 	val := p.Stack[0].Locals[i.Index]
 	if val == nil {
-		panic(fmt.Sprintf("global variable %s read before assignment", i.Name))
+		panic(lib.Errorf(lib.Uninitialized, "global variable %s read before assignment", i.Name))
 	}
 	p.Push(val)
 }
@@ -170,7 +189,7 @@ func (i ParamPtr) Exec(p *Execution) {
 	val = *val.(*any)
 	if val == nil {
 		// The _value_ however could be nil.
-		panic(fmt.Sprintf("param variable %s read before assignment", i.Name))
+		panic(lib.Errorf(lib.Uninitialized, "param variable %s read before assignment", i.Name))
 	}
 	p.Push(val)
 }
@@ -210,7 +229,7 @@ type LocalVal struct {
 func (i LocalVal) Exec(p *Execution) {
 	val := p.Frame.Locals[i.Index]
 	if val == nil {
-		panic(fmt.Sprintf("local %s read before assignment", i.Name))
+		panic(lib.Errorf(lib.Uninitialized, "local %s read before assignment", i.Name))
 	}
 	p.Push(val)
 }
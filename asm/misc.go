@@ -27,7 +27,10 @@ func (i Dup) Exec(p *Execution) {
 }
 
 func (i Dup) String() string {
-	return "dup"
+	if i.Skip == 0 {
+		return "dup"
+	}
+	return fmt.Sprintf("dup(%d)", i.Skip)
 }
 
 type Pop struct {
@@ -16,7 +16,7 @@ func (i UnaryOpInt) Exec(p *Execution) {
 	a := p.Pop().(int64)
 	switch i.Op {
 	case ast.NEG:
-		p.Push(-a)
+		p.Push(ast.IntegerNeg(a))
 	default:
 		panic(i.Op)
 	}
@@ -75,7 +75,7 @@ type IncrInt struct {
 func (i IncrInt) Exec(p *Execution) {
 	ref := p.Pop().(*any)
 	refVal := (*ref).(int64)
-	*ref = refVal + i.Val
+	*ref = ast.IntegerOp(ast.ADD, refVal, i.Val)
 }
 
 func (i IncrInt) String() string {
@@ -91,7 +91,7 @@ type IncrReal struct {
 func (i IncrReal) Exec(p *Execution) {
 	ref := p.Pop().(*any)
 	refVal := (*ref).(float64)
-	*ref = refVal + i.Val
+	*ref = ast.RealOp(ast.ADD, refVal, i.Val)
 }
 
 func (i IncrReal) String() string {
@@ -1,7 +1,9 @@
 package asm
 
 import (
+	"cmp"
 	"runtime"
+	"slices"
 	"strings"
 )
 
@@ -45,3 +47,12 @@ type baseInst struct {
 func (baseInst) Sym() string {
 	return ""
 }
+
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
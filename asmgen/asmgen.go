@@ -5,6 +5,7 @@ import (
 	"github.com/dragonsinth/gaddis/ast"
 	"github.com/dragonsinth/gaddis/base"
 	"github.com/dragonsinth/gaddis/lib"
+	"math"
 )
 
 func AssembleExpression(as *asm.Assembly, expr ast.Expression) []asm.Inst {
@@ -17,31 +18,8 @@ func AssembleExpression(as *asm.Assembly, expr ast.Expression) []asm.Inst {
 }
 
 func Assemble(prog *ast.Program) *asm.Assembly {
-	tv := &TempVisitor{}
-	prog.Visit(tv)
-
-	v := &Visitor{}
-	nClasses := len(prog.Scope.Classes)
-	v.vtables = make([]asm.Vtable, nClasses)
-
-	// Map the global scope up front.
-	for _, stmt := range prog.Block.Statements {
-		switch stmt := stmt.(type) {
-		case ast.Callable:
-			v.newLabel(stmt)
-		case *ast.ClassStmt:
-			// synthesize a "New" function
-			v.newLabelName(stmt.Name + "$new$")
-			for _, cs := range stmt.Block.Statements {
-				switch cs := cs.(type) {
-				case ast.Callable:
-					v.newLabel(cs)
-				}
-			}
-		default:
-			// nothing
-		}
-	}
+	as := &asm.Assembly{}
+	v := newVisitor(as, prog)
 
 	// Emit the global block's begin statement.
 	globalLabel := &asm.Label{Name: "global$"}
@@ -54,13 +32,7 @@ func Assemble(prog *ast.Program) *asm.Assembly {
 	})
 
 	// Emit all global block non-decls.
-	for _, stmt := range prog.Block.Statements {
-		switch stmt := stmt.(type) {
-		case *ast.ModuleStmt, *ast.FunctionStmt, *ast.ClassStmt:
-		default:
-			stmt.Visit(v)
-		}
-	}
+	v.emitGlobalStatements(prog)
 
 	// If there is a module named main with no arguments, call it at the very end.
 	finalReturnSi := prog.Block.SourceInfo.Tail()
@@ -82,6 +54,74 @@ func Assemble(prog *ast.Program) *asm.Assembly {
 	})
 
 	// Now emit all modules and functions.
+	v.emitDecls(prog)
+	v.finish(as, prog)
+	return as
+}
+
+// AssembleIncremental appends the code for another program sharing an earlier program's global
+// scope, as built by [collect.CollectInto]. The new global statements start at the returned pc, run
+// in the existing global frame, and end in a halt rather than returning.
+func AssembleIncremental(as *asm.Assembly, prog *ast.Program) int {
+	v := newVisitor(as, prog)
+
+	start := len(v.code)
+	v.emitGlobalStatements(prog)
+	v.code = append(v.code, asm.Halt{SourceInfo: prog.Block.SourceInfo.Tail(), NVal: 0})
+
+	v.emitDecls(prog)
+	v.finish(as, prog)
+	return start
+}
+
+func newVisitor(as *asm.Assembly, prog *ast.Program) *Visitor {
+	tv := &TempVisitor{}
+	prog.Visit(tv)
+
+	v := &Visitor{
+		code:    as.Code,
+		labels:  as.Labels,
+		vtables: make([]asm.Vtable, len(prog.Scope.Classes)),
+	}
+	for i, s := range as.Strings {
+		if v.strings == nil {
+			v.strings = map[string]int{}
+		}
+		v.strings[s] = i
+	}
+
+	// Map the global scope up front.
+	for _, stmt := range prog.Block.Statements {
+		switch stmt := stmt.(type) {
+		case ast.Callable:
+			v.newLabel(stmt)
+		case *ast.ClassStmt:
+			// synthesize a "New" function
+			v.newLabelName(stmt.Name + "$new$")
+			for _, cs := range stmt.Block.Statements {
+				switch cs := cs.(type) {
+				case ast.Callable:
+					v.newLabel(cs)
+				}
+			}
+		default:
+			// nothing
+		}
+	}
+	return v
+}
+
+func (v *Visitor) emitGlobalStatements(prog *ast.Program) {
+	for _, stmt := range prog.Block.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.ModuleStmt, *ast.FunctionStmt, *ast.ClassStmt:
+		default:
+			stmt.Visit(v)
+		}
+	}
+}
+
+func (v *Visitor) emitDecls(prog *ast.Program) {
 	for _, stmt := range prog.Block.Statements {
 		switch stmt := stmt.(type) {
 		case *ast.ModuleStmt, *ast.FunctionStmt:
@@ -98,13 +138,16 @@ func Assemble(prog *ast.Program) *asm.Assembly {
 			// nothing
 		}
 	}
+}
 
+func (v *Visitor) finish(as *asm.Assembly, prog *ast.Program) {
 	strings := make([]string, len(v.strings))
 	for s, i := range v.strings {
 		strings[i] = s
 	}
 
 	// compute vtables now that all the labels are known
+	nClasses := len(prog.Scope.Classes)
 	lblTables := make([][]*asm.Label, nClasses)
 	classes := make([]string, nClasses)
 	for i, c := range prog.Scope.Classes {
@@ -116,14 +159,12 @@ func Assemble(prog *ast.Program) *asm.Assembly {
 		}
 	}
 
-	return &asm.Assembly{
-		GlobalScope: prog.Scope,
-		Code:        v.code,
-		Labels:      v.labels,
-		Strings:     strings,
-		Classes:     classes,
-		Vtables:     lblTables,
-	}
+	as.GlobalScope = prog.Scope
+	as.Code = v.code
+	as.Labels = v.labels
+	as.Strings = strings
+	as.Classes = classes
+	as.Vtables = lblTables
 }
 
 type Visitor struct {
@@ -173,6 +214,13 @@ func (v *Visitor) emitInitializer(vd *ast.VarDecl) {
 				Val:        lib.InputFile{},
 				Id:         0,
 			})
+		case ast.RandomFile:
+			v.code = append(v.code, asm.Literal{
+				SourceInfo: vd.SourceInfo,
+				Typ:        vd.Type,
+				Val:        lib.RandomFile{},
+				Id:         0,
+			})
 		default:
 			panic(vd.Type)
 		}
@@ -193,11 +241,15 @@ func (v *Visitor) PreVisitDisplayStmt(d *ast.DisplayStmt) bool {
 			arg.Visit(v)
 		}
 	}
+	name := "Display"
+	if d.IsPrint {
+		name = "Print"
+	}
 	v.code = append(v.code, asm.LibCall{
 		SourceInfo: d.GetSourceInfo(),
-		Name:       "Display",
+		Name:       name,
 		Type:       ast.UnresolvedType,
-		Index:      lib.IndexOf("Display"),
+		Index:      lib.IndexOf(name),
 		NArg:       len(d.Exprs),
 	})
 	return false
@@ -221,21 +273,42 @@ func (v *Visitor) PreVisitInputStmt(i *ast.InputStmt) bool {
 func (v *Visitor) PreVisitSetStmt(i *ast.SetStmt) bool {
 	v.emitAssignment(i.SourceInfo, i.Ref, func() {
 		v.maybeCast(i.Ref.GetType(), i.Expr)
+		if needsSizeCheck(i) {
+			// the stack holds [ref, new]; check the new array against the old one before storing
+			v.code = append(v.code,
+				asm.Dup{SourceInfo: i.SourceInfo, Skip: 1},
+				asm.Deref{SourceInfo: i.SourceInfo},
+				asm.Dup{SourceInfo: i.SourceInfo, Skip: 1},
+				asm.LibCall{
+					SourceInfo: i.SourceInfo,
+					Name:       "$checkArraySize",
+					Type:       ast.UnresolvedType,
+					Index:      lib.IndexOf("$checkArraySize"),
+					NArg:       2,
+				},
+			)
+		}
 	})
 	return false
 }
 
+// needsSizeCheck reports whether a whole-array Set needs a runtime size check, because typecheck
+// couldn't compare the sizes.
+func needsSizeCheck(ss *ast.SetStmt) bool {
+	return ss.Ref.GetType().IsArrayType() && (ast.StaticDims(ss.Ref) == nil || ast.StaticDims(ss.Expr) == nil)
+}
+
 func (v *Visitor) emitAssignment(si ast.SourceInfo, lhs ast.Expression, emitRhs func()) {
 	if isStringCharAssignment(lhs) {
 		ar := lhs.(*ast.ArrayRef)
 		if ar.Qualifier.CanReference() {
-			// str = stringWithCharUpdate(str string, idx int64, c byte)
+			// str = stringWithCharUpdate(str string, idx int64, c rune)
 			v.varRef(ar.Qualifier, true)
 			// duplicate and deref as argument 0
 			v.code = append(v.code, asm.Dup{SourceInfo: si, Skip: 0})
 			v.code = append(v.code, asm.Deref{SourceInfo: si})
 		} else {
-			// eval and ignore: stringWithCharUpdate(str string, idx int64, c byte)
+			// eval and ignore: stringWithCharUpdate(str string, idx int64, c rune)
 			ar.Qualifier.Visit(v)
 		}
 		ar.IndexExpr.Visit(v)
@@ -272,13 +345,22 @@ func (v *Visitor) PreVisitOpenStmt(os *ast.OpenStmt) bool {
 	v.varRef(os.File, true)
 	os.File.Visit(v)
 	os.Name.Visit(v)
+	if os.RecordSize != nil {
+		os.RecordSize.Visit(v)
+	} else {
+		v.code = append(v.code, asm.Literal{
+			SourceInfo: os.SourceInfo,
+			Typ:        ast.Integer,
+			Val:        int64(os.Format),
+		})
+	}
 	name := "Open" + os.File.GetType().String()
 	v.code = append(v.code, asm.LibCall{
 		SourceInfo: os.SourceInfo,
 		Name:       name,
 		Type:       os.File.GetType(),
 		Index:      lib.IndexOf(name),
-		NArg:       2,
+		NArg:       3,
 	})
 	v.store(os)
 	return false
@@ -286,9 +368,12 @@ func (v *Visitor) PreVisitOpenStmt(os *ast.OpenStmt) bool {
 
 func (v *Visitor) PreVisitCloseStmt(cs *ast.CloseStmt) bool {
 	var name string
-	if cs.File.GetType() == ast.InputFile {
+	switch cs.File.GetType() {
+	case ast.InputFile:
 		name = "CloseInputFile"
-	} else {
+	case ast.RandomFile:
+		name = "CloseRandomFile"
+	default:
 		name = "CloseOutputFile"
 	}
 	cs.File.Visit(v)
@@ -313,20 +398,29 @@ func (v *Visitor) PreVisitReadStmt(rs *ast.ReadStmt) bool {
 			Skip:       1,
 		})
 
-		// non-final field sep -> final record sep
+		// field index, and field count of the record except for random access records
 		v.code = append(v.code, asm.Literal{
 			SourceInfo: rs.GetSourceInfo(),
-			Typ:        ast.Boolean,
-			Val:        i < len(rs.Exprs)-1,
+			Typ:        ast.Integer,
+			Val:        int64(i),
 		})
-
-		name := "Read" + arg.GetType().String()
+		name, nArg := "Read"+arg.GetType().String(), 2
+		if rs.File.GetType() == ast.RandomFile {
+			name = "ReadRandom" + arg.GetType().String()
+		} else {
+			v.code = append(v.code, asm.Literal{
+				SourceInfo: rs.GetSourceInfo(),
+				Typ:        ast.Integer,
+				Val:        int64(len(rs.Exprs)),
+			})
+			nArg = 3
+		}
 		v.code = append(v.code, asm.LibCall{
 			SourceInfo: rs.GetSourceInfo(),
 			Name:       name,
 			Type:       ast.UnresolvedType,
 			Index:      lib.IndexOf(name),
-			NArg:       2,
+			NArg:       nArg,
 		})
 		v.store(rs)
 	}
@@ -340,11 +434,15 @@ func (v *Visitor) PreVisitWriteStmt(ws *ast.WriteStmt) bool {
 	for _, arg := range ws.Exprs {
 		arg.Visit(v)
 	}
+	name := "WriteFile"
+	if ws.File.GetType() == ast.RandomFile {
+		name = "WriteRandomFile"
+	}
 	v.code = append(v.code, asm.LibCall{
 		SourceInfo: ws.GetSourceInfo(),
-		Name:       "WriteFile",
+		Name:       name,
 		Type:       ast.UnresolvedType,
-		Index:      lib.IndexOf("WriteFile"),
+		Index:      lib.IndexOf(name),
 		NArg:       len(ws.Exprs) + 1,
 	})
 	return false
@@ -519,7 +617,29 @@ func (v *Visitor) PreVisitForStmt(fs *ast.ForStmt) bool {
 	v.code = append(v.code, asm.Dup{SourceInfo: fs.Ref.GetSourceInfo(), Skip: 0})
 	switch refType {
 	case ast.Integer:
-		v.code = append(v.code, asm.IncrInt{SourceInfo: si, Val: intVal})
+		// if the next step would overflow, the counter can't pass the end value; stop here
+		// instead of trapping, e.g. in a loop ending at the largest Integer
+		popLabel := &asm.Label{Name: "fpop", PC: 0}
+		var limitOp ast.Operator
+		var limit int64
+		if intVal < 0 {
+			limitOp, limit = ast.LT, math.MinInt64-intVal
+		} else {
+			limitOp, limit = ast.GT, math.MaxInt64-intVal
+		}
+		v.code = append(v.code,
+			asm.Dup{SourceInfo: si, Skip: 0},
+			asm.Deref{SourceInfo: si},
+			asm.Literal{SourceInfo: si, Typ: ast.Integer, Val: limit},
+			asm.BinOpInt{SourceInfo: si, Op: limitOp},
+			asm.JumpTrue{SourceInfo: si, Label: popLabel},
+			asm.IncrInt{SourceInfo: si, Val: intVal},
+			asm.Jump{SourceInfo: si, Label: startLabel},
+		)
+		popLabel.PC = len(v.code)
+		v.code = append(v.code, asm.Pop{SourceInfo: si}, asm.Pop{SourceInfo: si})
+		endLabel.PC = len(v.code)
+		return false
 	case ast.Real:
 		v.code = append(v.code, asm.IncrReal{SourceInfo: si, Val: floatVal})
 	default:
@@ -582,6 +702,12 @@ func (v *Visitor) PreVisitForEachStmt(fs *ast.ForEachStmt) bool {
 	return false
 }
 
+func (v *Visitor) PreVisitReturnStmt(rs *ast.ReturnStmt) bool {
+	v.maybeCast(rs.Ref.Type, rs.Expr)
+	v.PostVisitReturnStmt(rs)
+	return false
+}
+
 func (v *Visitor) PostVisitReturnStmt(rs *ast.ReturnStmt) {
 	v.code = append(v.code, asm.Return{
 		SourceInfo: rs.SourceInfo,
@@ -593,7 +719,7 @@ func (v *Visitor) PreVisitCallStmt(cs *ast.CallStmt) bool {
 	si := cs.SourceInfo
 	if isExternalDeleteInsert(cs) {
 		// special case this, e.g.: s := insertString(s, pos, add)
-		// str = stringWithCharUpdate(str string, idx int64, c byte)
+		// str = stringWithCharUpdate(str string, idx int64, c rune)
 		v.varRef(cs.Args[0], true)
 		// duplicate and deref as argument 0
 		v.code = append(v.code, asm.Dup{SourceInfo: si, Skip: 0})
@@ -612,6 +738,13 @@ func (v *Visitor) PreVisitCallStmt(cs *ast.CallStmt) bool {
 		return false
 	}
 
+	if cs.Ref.IsExternal && cs.Name == "exit" {
+		// halt with the exit code
+		v.outputArguments(cs.Args, cs.Ref.Params)
+		v.code = append(v.code, asm.Halt{SourceInfo: si, NVal: 1, Exit: true})
+		return false
+	}
+
 	nArg := len(cs.Args)
 	if cs.Qualifier != nil {
 		cs.Qualifier.Visit(v)
@@ -755,16 +888,7 @@ func (v *Visitor) emitNewFunction(cs *ast.ClassStmt) {
 func (v *Visitor) PostVisitLiteral(l *ast.Literal) {
 	var id int
 	if l.Type == ast.String && !l.IsTabLiteral {
-		val := l.Val.(string)
-		if existing, ok := v.strings[val]; ok {
-			id = existing
-		} else {
-			if v.strings == nil {
-				v.strings = map[string]int{}
-			}
-			id = len(v.strings)
-			v.strings[val] = id
-		}
+		id = v.stringId(l.Val.(string))
 	}
 
 	v.code = append(v.code, asm.Literal{
@@ -775,6 +899,19 @@ func (v *Visitor) PostVisitLiteral(l *ast.Literal) {
 	})
 }
 
+// stringId interns a string literal in the string table.
+func (v *Visitor) stringId(val string) int {
+	if existing, ok := v.strings[val]; ok {
+		return existing
+	}
+	if v.strings == nil {
+		v.strings = map[string]int{}
+	}
+	id := len(v.strings)
+	v.strings[val] = id
+	return id
+}
+
 func (v *Visitor) PreVisitBinaryOperation(l *ast.BinaryOperation) bool {
 	v.maybeCast(l.ArgType, l.Lhs)
 	v.maybeCast(l.ArgType, l.Rhs)
@@ -806,13 +943,20 @@ func (v *Visitor) PreVisitCallExpr(ce *ast.CallExpr) bool {
 		ce.Qualifier.Visit(v)
 		nArg++
 	}
-	v.outputArguments(ce.Args, ce.Ref.Params)
+	if n := len(ce.Ref.Params); ce.Ref.IsVariadic {
+		v.outputArguments(ce.Args[:n], ce.Ref.Params)
+		for _, arg := range ce.Args[n:] {
+			arg.Visit(v)
+		}
+	} else {
+		v.outputArguments(ce.Args, ce.Ref.Params)
+	}
 	if ce.Ref.IsExternal {
 		v.code = append(v.code, asm.LibCall{
 			SourceInfo: ce.SourceInfo,
 			Name:       ce.Name,
 			Type:       ce.Type.AsPrimitive(),
-			Index:      lib.IndexOf(ce.Name),
+			Index:      lib.IndexOf(ce.Ref.Name),
 			NArg:       nArg,
 		})
 	} else if ce.Qualifier != nil {
@@ -922,6 +1066,14 @@ func (v *Visitor) maybeCast(dstType ast.Type, exp ast.Expression) {
 		v.code = append(v.code, asm.RealToInt{SourceInfo: exp.GetSourceInfo()})
 	} else if dstType == ast.String && exp.GetType() == ast.Character {
 		v.code = append(v.code, asm.CharToString{SourceInfo: exp.GetSourceInfo()})
+	} else if dstType.IsArrayType() && !ast.IsFreshArray(exp) {
+		// arrays are copied on assignment and return, just like pass by value
+		at := dstType.AsArrayType()
+		v.code = append(v.code, asm.ArrayClone{
+			SourceInfo: exp.GetSourceInfo(),
+			Typ:        at,
+			NDims:      at.NDims,
+		})
 	}
 }
 
@@ -962,10 +1114,15 @@ func (v *Visitor) varRefDecl(hs ast.HasSourceInfo, qual ast.Expression, decl *as
 		if val == nil {
 			panic("here")
 		}
+		var id int
+		if str, ok := val.(string); ok {
+			id = v.stringId(str)
+		}
 		v.code = append(v.code, asm.Literal{
 			SourceInfo: hs.GetSourceInfo(),
 			Typ:        decl.Type.AsPrimitive(),
 			Val:        val,
+			Id:         id,
 		})
 	} else if decl.Scope.IsGlobal {
 		if isRef {
@@ -1110,11 +1267,15 @@ func (v *Visitor) refLabelName(name string) *asm.Label {
 }
 
 func (v *Visitor) zero(si ast.SourceInfo, typ ast.Type) {
+	var id int
+	if typ == ast.String {
+		id = v.stringId("")
+	}
 	v.code = append(v.code, asm.Literal{
 		SourceInfo: si,
 		Typ:        typ,
 		Val:        asm.ZeroValue(typ),
-		Id:         0,
+		Id:         id,
 	})
 }
 
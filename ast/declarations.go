@@ -99,11 +99,13 @@ type FunctionStmt struct {
 	Block  *Block
 
 	IsExternal bool
+	IsVariadic bool // only if external; accepts any number of trailing primitive args
 	IsPrivate  bool
 	Enclosing  *ClassType
 
-	Scope *Scope // collect
-	Id    int    // only if method
+	Scope     *Scope          // collect
+	Id        int             // only if method
+	Overloads []*FunctionStmt // only if external
 }
 
 func (fs *FunctionStmt) Visit(v Visitor) {
@@ -1,15 +1,45 @@
 package ast
 
 import (
+	"fmt"
 	"math"
+	"strconv"
 )
 
+// ArithmeticError reports an integer overflow, a division by zero, or a Real result that is not
+// a finite number. Integer arithmetic traps rather than wrapping around, and Real arithmetic traps
+// rather than producing an infinity or NaN.
+type ArithmeticError struct {
+	DivByZero bool
+	Msg       string
+}
+
+func (e ArithmeticError) Error() string {
+	return e.Msg
+}
+
 func AnyOp(op Operator, argType PrimitiveType, a, b any) any {
 	defer func() {
 		// evaluation can panic; just return nil
 		recover()
 	}()
+	return anyOp(op, argType, a, b)
+}
+
+// CheckOp evaluates a constant operation, returning the ArithmeticError it raises, if any.
+func CheckOp(op Operator, argType PrimitiveType, a, b any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ae, ok := r.(ArithmeticError); ok {
+				err = ae
+			}
+		}
+	}()
+	anyOp(op, argType, a, b)
+	return nil
+}
 
+func anyOp(op Operator, argType PrimitiveType, a, b any) any {
 	switch argType {
 	case Integer:
 		return IntegerOp(op, a.(int64), b.(int64))
@@ -20,7 +50,7 @@ func AnyOp(op Operator, argType PrimitiveType, a, b any) any {
 	case String:
 		return StringOp(op, a.(string), b.(string))
 	case Character:
-		return CharacterOp(op, a.(byte), b.(byte))
+		return CharacterOp(op, a.(rune), b.(rune))
 	case Boolean:
 		return BooleanOp(op, a.(bool), b.(bool))
 	default:
@@ -31,16 +61,33 @@ func AnyOp(op Operator, argType PrimitiveType, a, b any) any {
 func IntegerOp(op Operator, a, b int64) any {
 	switch op {
 	case ADD:
-		return a + b
+		c := a + b
+		if (c > a) != (b > 0) {
+			integerOverflow(op, a, b)
+		}
+		return c
 	case SUB:
-		return a - b
+		c := a - b
+		if (c < a) != (b > 0) {
+			integerOverflow(op, a, b)
+		}
+		return c
 	case MUL:
-		return a * b
+		return mulInteger(a, b)
 	case DIV:
+		if b == 0 {
+			integerDivByZero(op, a, b)
+		}
+		if a == math.MinInt64 && b == -1 {
+			integerOverflow(op, a, b)
+		}
 		return a / b
 	case EXP:
 		return expInteger(a, b)
 	case MOD:
+		if b == 0 {
+			integerDivByZero(op, a, b)
+		}
 		return a % b
 	case EQ:
 		return a == b
@@ -62,17 +109,23 @@ func IntegerOp(op Operator, a, b int64) any {
 func RealOp(op Operator, a, b float64) any {
 	switch op {
 	case ADD:
-		return a + b
+		return checkReal(op, a, b, a+b)
 	case SUB:
-		return a - b
+		return checkReal(op, a, b, a-b)
 	case MUL:
-		return a * b
+		return checkReal(op, a, b, a*b)
 	case DIV:
-		return a / b
+		if b == 0 {
+			panic(ArithmeticError{DivByZero: true, Msg: fmt.Sprintf("real division by zero: %s", formatRealOp(op, a, b))})
+		}
+		return checkReal(op, a, b, a/b)
 	case EXP:
-		return math.Pow(a, b)
+		return checkReal(op, a, b, math.Pow(a, b))
 	case MOD:
-		return math.Mod(a, b)
+		if b == 0 {
+			panic(ArithmeticError{DivByZero: true, Msg: fmt.Sprintf("real division by zero: %s", formatRealOp(op, a, b))})
+		}
+		return checkReal(op, a, b, math.Mod(a, b))
 	case EQ:
 		return a == b
 	case NEQ:
@@ -109,7 +162,7 @@ func StringOp(op Operator, a, b string) any {
 	}
 }
 
-func CharacterOp(op Operator, a, b byte) any {
+func CharacterOp(op Operator, a, b rune) any {
 	switch op {
 	case EQ:
 		return a == b
@@ -152,19 +205,69 @@ func expInteger(base, exp int64) int64 {
 	}
 
 	result := int64(1)
-	for {
-		if exp&1 == 1 { // Check if the least significant bit of exp is 1
-			result *= base
+	for b, e := base, exp; ; {
+		if e&1 == 1 { // Check if the least significant bit of exp is 1
+			result = mulChecked(result, b, EXP, base, exp)
 		}
-		exp >>= 1 // Right shift exp (equivalent to dividing by 2)
-		if exp == 0 {
+		e >>= 1 // Right shift exp (equivalent to dividing by 2)
+		if e == 0 {
 			break
 		}
-		base *= base // Square the base
+		b = mulChecked(b, b, EXP, base, exp) // Square the base
 	}
 	return result
 }
 
+// IntegerNeg negates a, trapping on overflow.
+func IntegerNeg(a int64) int64 {
+	if a == math.MinInt64 {
+		panic(ArithmeticError{Msg: fmt.Sprintf("integer overflow: -(%d)", a)})
+	}
+	return -a
+}
+
+func mulInteger(a, b int64) int64 {
+	return mulChecked(a, b, MUL, a, b)
+}
+
+// mulChecked multiplies a and b, reporting an overflow as the operation "x op y".
+func mulChecked(a, b int64, op Operator, x, y int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		integerOverflow(op, x, y)
+	}
+	return c
+}
+
+func integerOverflow(op Operator, a, b int64) {
+	panic(ArithmeticError{Msg: fmt.Sprintf("integer overflow: %d %s %d", a, op, b)})
+}
+
+func integerDivByZero(op Operator, a, b int64) {
+	panic(ArithmeticError{DivByZero: true, Msg: fmt.Sprintf("integer division by zero: %d %s %d", a, op, b)})
+}
+
+// checkReal reports an infinite or NaN result from finite operands.
+func checkReal(op Operator, a, b, ret float64) float64 {
+	if !math.IsNaN(ret) && !math.IsInf(ret, 0) {
+		return ret
+	}
+	if math.IsNaN(a) || math.IsInf(a, 0) || math.IsNaN(b) || math.IsInf(b, 0) {
+		return ret // garbage in, garbage out
+	}
+	if math.IsInf(ret, 0) {
+		panic(ArithmeticError{Msg: fmt.Sprintf("real overflow: %s", formatRealOp(op, a, b))})
+	}
+	panic(ArithmeticError{Msg: fmt.Sprintf("real result is not a number: %s", formatRealOp(op, a, b))})
+}
+
+func formatRealOp(op Operator, a, b float64) string {
+	return fmt.Sprintf("%s %s %s", strconv.FormatFloat(a, 'g', -1, 64), op, strconv.FormatFloat(b, 'g', -1, 64))
+}
+
 func EnsureReal(x any) float64 {
 	switch v := x.(type) {
 	case float64:
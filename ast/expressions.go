@@ -1,5 +1,7 @@
 package ast
 
+import "math"
+
 type Expression interface {
 	Node
 	GetType() Type
@@ -92,6 +94,9 @@ func (uo *UnaryOperation) ConstEval() any {
 	case NEG:
 		switch v := expr.(type) {
 		case int64:
+			if v == math.MinInt64 {
+				return nil // overflow
+			}
 			return -v
 		case float64:
 			return -v
@@ -289,3 +294,28 @@ func (ne *NewExpr) Visit(v Visitor) {
 func (ne *NewExpr) GetType() Type {
 	return ne.Type
 }
+
+// IsFreshArray reports whether exp produces a new array no one else references, which needs no copy.
+func IsFreshArray(exp Expression) bool {
+	switch exp.(type) {
+	case *CallExpr, *ArrayInitializer:
+		return true
+	default:
+		return false
+	}
+}
+
+// StaticDims returns the declared sizes of an array expression, if they are known at compile time.
+func StaticDims(expr Expression) []int {
+	switch e := expr.(type) {
+	case *VariableExpr:
+		if e.Ref != nil && !e.Ref.IsParam {
+			return e.Ref.Dims
+		}
+	case *ArrayRef:
+		if dims := StaticDims(e.Qualifier); len(dims) > 1 {
+			return dims[1:]
+		}
+	}
+	return nil
+}
@@ -15,15 +15,43 @@ func computeExternalScope() *Scope {
 	}
 
 	for _, entry := range lib.GetLibrary() {
-		ret.Decls[entry.Name] = translateMethod(ret, entry.Name, entry.FuncPtr.Type())
+		decl := translateMethod(ret, entry.Name, entry.FuncPtr.Type())
+		name := entry.Name
+		if entry.Overload != "" {
+			name = entry.Overload
+		}
+		if existing := ret.Decls[name]; existing != nil {
+			// chain the overload onto the first definition
+			fs := existing.FunctionStmt
+			if len(fs.Overloads) == 0 {
+				fs.Overloads = []*FunctionStmt{fs}
+			}
+			fs.Overloads = append(fs.Overloads, decl.FunctionStmt)
+		} else {
+			ret.Decls[name] = decl
+		}
+	}
+
+	for _, c := range lib.GetConstants() {
+		typ, _ := translateType(reflect.TypeOf(c.Val))
+		ret.AddVariable(&VarDecl{
+			Name:    c.Name,
+			Type:    typ,
+			Expr:    &Literal{Type: typ.AsPrimitive(), Val: c.Val},
+			IsConst: true,
+		})
 	}
 
 	return ret
 }
 
 func translateMethod(scope *Scope, name string, methodType reflect.Type) *Decl {
+	nParams := methodType.NumIn()
+	if methodType.IsVariadic() {
+		nParams-- // trailing args are untyped
+	}
 	var params []*VarDecl
-	for i := 0; i < methodType.NumIn(); i++ {
+	for i := 0; i < nParams; i++ {
 		p := methodType.In(i)
 		typ, isRef := translateType(p)
 		params = append(params, &VarDecl{
@@ -33,8 +61,9 @@ func translateMethod(scope *Scope, name string, methodType reflect.Type) *Decl {
 		})
 	}
 
-	if name == "insert" || name == "delete" {
-		// special case!
+	if name == "insert" || name == "delete" || methodType.NumOut() == 0 {
+		// functions without a result, like seek, are Modules; special case! insert and delete
+		// return their result in Go, but are Modules which modify their first argument
 		ms := &ModuleStmt{
 			SourceInfo: SourceInfo{},
 			Name:       name,
@@ -52,6 +81,7 @@ func translateMethod(scope *Scope, name string, methodType reflect.Type) *Decl {
 		Type:       returnType,
 		Params:     params,
 		IsExternal: true,
+		IsVariadic: methodType.IsVariadic(),
 		Scope:      scope,
 	}
 	return &Decl{FunctionStmt: fs}
@@ -64,11 +94,30 @@ func translateType(inType reflect.Type) (Type, bool) {
 		inType = inType.Elem()
 	}
 
+	return translateValueType(inType), isRef
+}
+
+func translateValueType(inType reflect.Type) Type {
+	if inType.Kind() == reflect.Slice {
+		elementType := translateValueType(inType.Elem())
+		base, nDims := elementType, 1
+		if elementType.IsArrayType() {
+			at := elementType.AsArrayType()
+			base, nDims = at.Base, at.NDims+1
+		}
+		return &ArrayType{
+			Base:        base,
+			NDims:       nDims,
+			ElementType: elementType,
+			TypeKey:     elementType.Key() + "[]",
+		}
+	}
+
 	t, ok := reverseTypeMap[inType.String()]
 	if !ok {
 		panic(inType.String())
 	}
-	return t, isRef
+	return t
 }
 
 var reverseTypeMap = map[string]Type{
@@ -77,10 +126,11 @@ var reverseTypeMap = map[string]Type{
 	"int64":   Integer,
 	"float64": Real,
 	"string":  String,
-	"uint8":   Character,
+	"int32":   Character,
 	"bool":    Boolean,
 
 	"lib.OutputFile": OutputFile,
 	"lib.AppendFile": AppendFile,
 	"lib.InputFile":  InputFile,
+	"lib.RandomFile": RandomFile,
 }
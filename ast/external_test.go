@@ -1,7 +1,52 @@
 package ast
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestInit(t *testing.T) {
 	// just make sure external scope mounts
 }
+
+func TestTranslateArrayType(t *testing.T) {
+	tcs := []struct {
+		in    any
+		key   TypeKey
+		nDims int
+		isRef bool
+	}{
+		{[]int64{}, "Integer[]", 1, false},
+		{[][]float64{}, "Real[][]", 2, false},
+		{&[]string{}, "String[]", 1, true},
+		{&[][][]bool{}, "Boolean[][][]", 3, true},
+	}
+	for _, tc := range tcs {
+		typ, isRef := translateType(reflect.TypeOf(tc.in))
+		if !typ.IsArrayType() {
+			t.Fatalf("%T: expected array type, got %s", tc.in, typ)
+		}
+		at := typ.AsArrayType()
+		if at.Key() != tc.key || at.NDims != tc.nDims || isRef != tc.isRef {
+			t.Errorf("%T: got %s, %d dims, ref=%v", tc.in, at.Key(), at.NDims, isRef)
+		}
+		if at.BaseType().IsArrayType() {
+			t.Errorf("%T: base type should not be an array: %s", tc.in, at.BaseType())
+		}
+	}
+}
+
+func TestExternalOverloads(t *testing.T) {
+	decl := ExternalScope.Lookup("sum")
+	if decl == nil || decl.FunctionStmt == nil {
+		t.Fatal("sum not found")
+	}
+	var got []TypeKey
+	for _, fs := range decl.FunctionStmt.Overloads {
+		got = append(got, fs.Params[0].Type.Key())
+	}
+	want := []TypeKey{"Integer[]", "Real[]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -1,9 +1,41 @@
 package ast
 
+import "github.com/dragonsinth/gaddis/lib"
+
 type OpenStmt struct {
 	SourceInfo
-	File Expression
-	Name Expression
+	File   Expression
+	Name   Expression
+	Format FileFormat
+
+	RecordSize Expression // only for a RandomFile
+}
+
+// FileFormat is the on-disk format of a file, specified with `As` when opening a file.
+type FileFormat int
+
+const (
+	GaddisFormat = FileFormat(lib.GaddisFormat) // values are written as Gaddis literals
+	CSVFormat    = FileFormat(lib.CSVFormat)    // comma separated values
+	TextFormat   = FileFormat(lib.TextFormat)   // plain text, one value per line
+)
+
+var fileFormatNames = [...]string{
+	GaddisFormat: "",
+	CSVFormat:    "CSV",
+	TextFormat:   "Text",
+}
+
+func (f FileFormat) String() string { return fileFormatNames[f] }
+
+// ParseFileFormat returns the named file format, if valid.
+func ParseFileFormat(name string) (FileFormat, bool) {
+	for i, n := range fileFormatNames {
+		if n != "" && n == name {
+			return FileFormat(i), true
+		}
+	}
+	return GaddisFormat, false
 }
 
 func (os *OpenStmt) Visit(v Visitor) {
@@ -12,6 +44,9 @@ func (os *OpenStmt) Visit(v Visitor) {
 	}
 	os.File.Visit(v)
 	os.Name.Visit(v)
+	if os.RecordSize != nil {
+		os.RecordSize.Visit(v)
+	}
 	v.PostVisitOpenStmt(os)
 }
 
@@ -151,6 +151,7 @@ const (
 	OutputFile      = FileType(11)
 	AppendFile      = FileType(12)
 	InputFile       = FileType(13)
+	RandomFile      = FileType(14)
 )
 
 var fileTypeNames = [...]string{
@@ -158,6 +159,7 @@ var fileTypeNames = [...]string{
 	OutputFile:      "OutputFile",
 	AppendFile:      "AppendFile",
 	InputFile:       "InputFile",
+	RandomFile:      "RandomFile",
 }
 
 var _ Type = InvalidFileType
@@ -194,7 +196,7 @@ func (t FileType) isType() {
 }
 
 func CanCoerce(dst Type, src Type) bool {
-	if dst == src {
+	if IsSameType(dst, src) {
 		return true
 	}
 	if dst == Real && src == Integer {
@@ -209,6 +211,15 @@ func CanCoerce(dst Type, src Type) bool {
 	return false
 }
 
+// IsSameType compares types by identity; array types are compared by key,
+// since external array types are not interned with the program's types.
+func IsSameType(a Type, b Type) bool {
+	if a == b {
+		return true
+	}
+	return a.IsArrayType() && b.IsArrayType() && a.Key() == b.Key()
+}
+
 func IsSubclass(maybeSuper Type, maybeSub Type) bool {
 	if maybeSuper == maybeSub {
 		return false
@@ -167,6 +167,14 @@ func (v *Visitor) PreVisitOpenStmt(os *ast.OpenStmt) bool {
 	os.File.Visit(v)
 	v.output(" ")
 	os.Name.Visit(v)
+	if os.Format != ast.GaddisFormat {
+		v.output(" As ")
+		v.output(os.Format.String())
+	}
+	if os.RecordSize != nil {
+		v.output(", ")
+		os.RecordSize.Visit(v)
+	}
 	return false
 }
 
@@ -508,7 +516,7 @@ func (v *Visitor) PreVisitLiteral(l *ast.Literal) bool {
 	case ast.String:
 		v.output(strconv.Quote(l.Val.(string)))
 	case ast.Character:
-		v.output(strconv.QuoteRune(rune(l.Val.(byte))))
+		v.output(strconv.QuoteRune(l.Val.(rune)))
 	case ast.Boolean:
 		if l.Val.(bool) {
 			v.output("True")
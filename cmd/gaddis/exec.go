@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/asm"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// execCmd runs a bytecode file written by build -o, or an assembly file in the format of a -d dump.
+func execCmd(args []string, opts runOpts) error {
+	if len(args) != 1 {
+		return errors.New("expects 1 argument: the bytecode or .asm file to run")
+	}
+	filename := args[0]
+	if strings.HasSuffix(filename, ".asm") {
+		return execAsm(filename, opts)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	assembled, srcName, err := asm.ReadBytecode(f)
+	_ = f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if srcName == "" {
+		srcName = filename
+	}
+
+	// there's no source text, so errors and traces show only file and line
+	src := &source{filename: srcName}
+	return execAssembly(filename, src, opts, assembled)
+}
+
+var sourceCommentRe = regexp.MustCompile(`(?m)^;\s*\d+:`)
+
+// execAsm assembles and runs an assembly file.
+func execAsm(filename string, opts runOpts) error {
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	assembled, err := asm.Assemble(string(text))
+	if err != nil {
+		return fmt.Errorf("%s:%w", filename, err)
+	}
+
+	// a dump's line numbers are for the program it was dumped from, e.g. foo.gad for foo.gad.asm;
+	// hand-written assembly is its own source
+	src := &source{filename: filename, src: string(text)}
+	if sourceCommentRe.Match(text) {
+		src.filename = strings.TrimSuffix(filename, ".asm")
+		srcText, _ := os.ReadFile(src.filename)
+		src.src = string(srcText)
+	}
+	return execAssembly(filename, src, opts, assembled)
+}
+
+func execAssembly(filename string, src *source, opts runOpts, assembled *asm.Assembly) error {
+	streams := runStreams(src)
+	if opts.spoolPrint {
+		streams.PrintFile = filename + ".print.txt"
+	}
+	return runAssembly(src, opts, streams, assembled)
+}
@@ -5,17 +5,32 @@ import (
 	"flag"
 	"fmt"
 	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/lib"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 )
 
 var (
-	fVerbose = flag.Bool("v", false, "verbose logging")
-	fDebug   = flag.Bool("d", false, "don't delete any generated files, leave for inspection")
-	fJson    = flag.Bool("json", false, "emit errors as json")
-	fGogen   = flag.Bool("gogen", false, "run using go compile")
-	fPort    = flag.Int("port", -1, "debug: port to listen on; terminal: port to connect to")
+	fVerbose    = flag.Bool("v", false, "verbose logging")
+	fDebug      = flag.Bool("d", false, "don't delete any generated files, leave for inspection")
+	fJson       = flag.Bool("json", false, "emit errors as json")
+	fGogen      = flag.Bool("gogen", false, "run using go compile")
+	fPort       = flag.Int("port", -1, "debug: port to listen on; terminal: port to connect to")
+	fPrint      = flag.String("print", "stdout", "run: where Print output goes; stdout, or spool to <file>.print.txt")
+	fInput      = flag.String("input", "default", "Input prompts: default, none, custom (see -prompt), or failfast on invalid input")
+	fPrompt     = flag.String("prompt", "", "custom Input prompt; %s is replaced by the type, e.g. \"Enter a %s: \"")
+	fTranscript = flag.Bool("transcript", false, "echo Input to the output, producing a transcript")
+	fTrace      = flag.String("trace", "", "run: trace execution; comma-separated lines, calls (with arguments and return values), insts (with the eval stack)")
+	fTraceOut   = flag.String("traceout", "", "run: write the trace to this file instead of stderr")
+	fTraceFunc  = flag.String("tracefunc", "", "run: only trace these comma-separated Modules and Functions; global for the global block")
+	fRun        = flag.String("run", "", "test: only run test files whose path matches this regexp")
+	fUpdate     = flag.Bool("update", false, "test: rewrite .out and .print files that don't match the actual output")
+	fReport     = flag.String("report", "", "test: write results as junit, tap, or json instead; grade: csv (default) or json")
+	fOut        = flag.String("o", "", "grade: also write a JSON report for each student to this directory; profile: the pprof output file; build: write bytecode to this file")
+	fParallel   = flag.Int("parallel", runtime.NumCPU(), "test, grade: maximum number of test files or submissions to run at once")
 )
 
 const help = `Usage: gaddis <command> [options] [arguments]
@@ -23,14 +38,20 @@ const help = `Usage: gaddis <command> [options] [arguments]
 Available commands:
 
 run:      everything, including format
-test:     run in test mode
+test:     run in test mode; accepts files, directories, and dir/... patterns
+grade:    score submission directories against a rubric: gaddis grade rubric.json dir...
 format:   parse and format the input file
 check:    parse and error check the input file
-build:    parse, check, and build the input file
+build:    parse, check, and build the input file; with -o prog.gbc, write bytecode for exec
+exec:     run a bytecode file written by build -o, without the source, or an .asm file like -d writes
+profile:  run the input file, then report the instructions executed per line and per Module/Function
+repl:     interactively evaluate declarations, statements, and expressions
 debug:    run a DAP debug server on stdio or the given port (used by VSCode extension)
 terminal: run a simple netcat-like termimanl (used by VSCode extension for debug i/o)
 help:     print this help message
 version:  print version and exit
+
+Program arguments follow --, e.g. gaddis run file.gad -- arg1 arg2
 `
 
 var (
@@ -45,10 +66,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	// program arguments follow --
+	var progArgs []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, progArgs = args[:i], args[i+1:]
+	}
+
 	opts := runOpts{
 		stopAfterBuild:    false,
 		leaveBuildOutputs: *fDebug,
 		goGen:             *fGogen,
+		spoolPrint:        *fPrint == "spool",
+		args:              progArgs,
+		updateGolden:      *fUpdate,
+		trace:             *fTrace,
+		traceOut:          *fTraceOut,
+		traceFuncs:        *fTraceFunc,
+	}
+	if *fPrint != "stdout" && *fPrint != "spool" {
+		_, _ = fmt.Fprintf(os.Stderr, "Unknown -print option: %s\n", *fPrint)
+		os.Exit(1)
+	}
+	if mode, ok := lib.ParseInputMode(*fInput); !ok {
+		_, _ = fmt.Fprintf(os.Stderr, "Unknown -input option: %s\n", *fInput)
+		os.Exit(1)
+	} else {
+		if *fPrompt != "" && mode == lib.DefaultInput {
+			mode = lib.CustomPrompt // -prompt implies custom
+		}
+		opts.inputPolicy = lib.InputPolicy{Mode: mode, Prompt: *fPrompt, Echo: *fTranscript}
 	}
 
 	var err error
@@ -65,11 +111,20 @@ func main() {
 		// always leave build outputs on build command
 		opts.stopAfterBuild = true
 		opts.leaveBuildOutputs = true
+		opts.outFile = *fOut
 		err = runCmd(args[1:], opts)
+	case "exec":
+		err = execCmd(args[1:], opts)
 	case "run":
 		err = runCmd(args[1:], opts)
 	case "test":
 		err = test(args[1:], opts)
+	case "profile":
+		err = profileCmd(args[1:], opts, *fOut)
+	case "repl":
+		err = replCmd(opts)
+	case "grade":
+		err = gradeCmd(args[1:], *fParallel, *fReport, *fOut)
 	case "debug":
 		err = debugCmd(*fPort, *fVerbose)
 	case "terminal":
@@ -6,13 +6,15 @@ import (
 	"github.com/dragonsinth/gaddis/ast"
 	"github.com/dragonsinth/gaddis/goexec"
 	"github.com/dragonsinth/gaddis/gogen"
+	"github.com/dragonsinth/gaddis/lib"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 )
 
-func runGo(src *source, opts runOpts, isTest bool, streams *procStreams, prog *ast.Program) error {
-	goSrc := gogen.GoGenerate(prog, isTest)
+func runGo(src *source, opts runOpts, streams *procStreams, prog *ast.Program) error {
+	goSrc := gogen.GoGenerate(prog, false)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
@@ -36,10 +38,19 @@ func runGo(src *source, opts runOpts, isTest bool, streams *procStreams, prog *a
 		return nil
 	}
 
-	if err := goexec.Run(ctx, ".", br.ExeFile, streams.Stdin, streams.Stdout, os.Stderr); err != nil {
-		if streams.Silent {
-			_, _ = os.Stdout.Write(streams.Output.Bytes())
+	env := lib.InputPolicyEnv(opts.inputPolicy)
+	if streams.PrintFile != "" {
+		if err := streams.startPrint(); err != nil {
+			return err
 		}
+		printFile, err := filepath.Abs(streams.PrintFile)
+		if err != nil {
+			return err
+		}
+		env = append(env, lib.PrintEnv+"="+printFile)
+	}
+
+	if err := goexec.Run(ctx, ".", env, br.ExeFile, opts.args, streams.Stdin, streams.Stdout, os.Stderr); err != nil {
 		return err
 	}
 	return nil
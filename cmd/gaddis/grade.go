@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/grade"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func gradeCmd(args []string, parallel int, format string, outDir string) error {
+	if len(args) < 2 {
+		return errors.New("expects a rubric file and one or more submission directories")
+	}
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unknown -report format for grade: %s", format)
+	}
+
+	rubric, err := grade.LoadRubric(args[0])
+	if err != nil {
+		return err
+	}
+	dirs := args[1:]
+	for _, dir := range dirs {
+		if fi, err := os.Stat(dir); err != nil {
+			return err
+		} else if !fi.IsDir() {
+			return fmt.Errorf("%s: not a directory", dir)
+		}
+	}
+
+	students := studentNames(dirs)
+	reports := make([]*grade.Report, len(dirs))
+	sem := make(chan struct{}, max(1, parallel))
+	done := make(chan struct{})
+	for i, dir := range dirs {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			reports[i] = rubric.Grade(dir)
+			reports[i].Student = students[i]
+		}()
+	}
+	for range dirs {
+		<-done
+	}
+
+	if outDir != "" {
+		// per-student reports, safe to hand back to students
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return err
+		}
+		for _, rep := range reports {
+			var buf bytes.Buffer
+			if err := writeJson(&buf, rep.ForStudent()); err != nil {
+				return err
+			}
+			file := filepath.Join(outDir, filepath.FromSlash(rep.Student)+".json")
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+				return fmt.Errorf("writing to %s: %w", file, err)
+			}
+		}
+	}
+
+	if format == "json" {
+		return writeJson(os.Stdout, reports)
+	}
+	return writeGradesCsv(os.Stdout, rubric, reports)
+}
+
+// studentNames names each submission after its directory, e.g. "alice". Submissions in directories
+// with the same name are told apart by their parents, e.g. "sec1/alice" and "sec2/alice".
+func studentNames(dirs []string) []string {
+	parts := make([][]string, len(dirs))
+	depth := make([]int, len(dirs))
+	for i, dir := range dirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+			if part != "" && part != "." && part != ".." {
+				parts[i] = append(parts[i], part)
+			}
+		}
+		depth[i] = 1
+	}
+	name := func(i int) string {
+		p := parts[i]
+		return strings.Join(p[max(0, len(p)-depth[i]):], "/")
+	}
+
+	ret := make([]string, len(dirs))
+	for {
+		byName := map[string][]int{}
+		for i := range dirs {
+			ret[i] = name(i)
+			byName[ret[i]] = append(byName[ret[i]], i)
+		}
+		grew := false
+		for _, same := range byName {
+			for _, i := range same {
+				if len(same) > 1 && depth[i] < len(parts[i]) {
+					depth[i]++
+					grew = true
+				}
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+
+	// the same directory given twice
+	seen := map[string]int{}
+	for i, n := range ret {
+		if seen[n]++; seen[n] > 1 {
+			ret[i] = fmt.Sprintf("%s-%d", n, seen[n])
+		}
+	}
+	return ret
+}
+
+// writeGradesCsv writes one row per student, with a column per rubric item.
+func writeGradesCsv(w io.Writer, rubric *grade.Rubric, reports []*grade.Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{"student", "score", "max"}
+	if len(reports) > 0 {
+		for _, it := range reports[0].Items {
+			header = append(header, it.Name)
+		}
+	}
+	header = append(header, "error")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, rep := range reports {
+		row := []string{rep.Student, formatPoints(rep.Score), formatPoints(rubric.MaxPoints())}
+		for _, it := range rep.Items {
+			row = append(row, formatPoints(it.Points))
+		}
+		row = append(row, rep.Error)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJson(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // keep diffs and prompts like "integer> " readable
+	return enc.Encode(v)
+}
+
+func formatPoints(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStudentNames(t *testing.T) {
+	for _, tc := range []struct {
+		dirs []string
+		want []string
+	}{
+		{[]string{"subs/alice", "subs/bob/"}, []string{"alice", "bob"}},
+		{[]string{"sec1/alice", "sec2/alice", "sec2/bob"}, []string{"sec1/alice", "sec2/alice", "bob"}},
+		{[]string{"x/sec/alice", "y/sec/alice"}, []string{"x/sec/alice", "y/sec/alice"}},
+		{[]string{"/subs/alice", "/subs/alice"}, []string{"subs/alice", "subs/alice-2"}},
+		{[]string{"/subs/alice", "/subs/./bob/../alice"}, []string{"subs/alice", "subs/alice-2"}},
+	} {
+		if got := studentNames(tc.dirs); !slices.Equal(got, tc.want) {
+			t.Errorf("studentNames(%q) = %q, want %q", tc.dirs, got, tc.want)
+		}
+	}
+}
@@ -10,10 +10,17 @@ type procStreams struct {
 	Stdin  io.Reader
 	Stdout io.Writer
 
-	Input  bytes.Buffer // either prefilled with data, or captures all input
-	Output bytes.Buffer // captured output
+	Input bytes.Buffer // prefilled with data, if the program source came from stdin
 
-	Silent bool
+	PrintFile string // if set, printer output is spooled to this file instead of Stdout
+}
+
+// startPrint truncates the print spool file, if any.
+func (ps *procStreams) startPrint() error {
+	if ps.PrintFile == "" {
+		return nil
+	}
+	return os.WriteFile(ps.PrintFile, nil, 0666)
 }
 
 func runStreams(src *source) *procStreams {
@@ -30,31 +37,6 @@ func runStreams(src *source) *procStreams {
 	return &ret
 }
 
-func testStreams(src *source) *procStreams {
-	var ret procStreams
-	ret.Stdin = &ret.Input
-	ret.Stdout = &ret.Output
-	ret.Silent = true
-	if inBytes, err := os.ReadFile(src.filename + ".in"); err == nil {
-		// use input file as input
-		ret.Input.Write(inBytes)
-	}
-	return &ret
-}
-
-func captureStreams(src *source) *procStreams {
-	var ret procStreams
-	if src.isStdin {
-		// there can be no input, we already consumed it all
-		ret.Stdin = &ret.Input
-	} else {
-		// capture input from terminal
-		ret.Stdin = io.TeeReader(os.Stdin, &ret.Input)
-	}
-	ret.Stdout = io.MultiWriter(stdoutSyncWriter{}, &ret.Output)
-	return &ret
-}
-
 type stdoutSyncWriter struct{}
 
 func (stdoutSyncWriter) Write(p []byte) (n int, err error) {
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/dragonsinth/gaddis"
 	"github.com/dragonsinth/gaddis/asm"
 	"github.com/dragonsinth/gaddis/asmgen"
 	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"github.com/dragonsinth/gaddis/trace"
+	"io"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 )
 
-func runInterp(src *source, opts runOpts, isTest bool, streams *procStreams, prog *ast.Program) error {
+func runInterp(src *source, opts runOpts, streams *procStreams, prog *ast.Program) error {
 	assembled := asmgen.Assemble(prog)
 	if opts.leaveBuildOutputs {
 		asmFile := src.desc() + ".asm"
@@ -21,32 +28,95 @@ func runInterp(src *source, opts runOpts, isTest bool, streams *procStreams, pro
 		}
 	}
 
+	if opts.outFile != "" {
+		var buf bytes.Buffer
+		if err := assembled.WriteBytecode(&buf, src.desc()); err != nil {
+			return err
+		}
+		if err := os.WriteFile(opts.outFile, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("writing to %s: %w", opts.outFile, err)
+		}
+	}
+
 	if opts.stopAfterBuild {
 		return nil
 	}
+	return runAssembly(src, opts, streams, assembled)
+}
 
-	var seed int64
-	if !isTest {
-		seed = time.Now().UnixNano()
+// runAssembly runs an assembled program; src may have no source text, if it was loaded from bytecode.
+func runAssembly(src *source, opts runOpts, streams *procStreams, assembled *asm.Assembly) error {
+	iop := gaddis.IoAdapter{
+		In:      gaddis.StreamInput(streams.Stdin),
+		Out:     gaddis.StreamOutput(streams.Stdout),
+		WorkDir: ".",
+		Policy:  opts.inputPolicy,
+	}
+	if streams.PrintFile != "" {
+		if err := streams.startPrint(); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(streams.PrintFile, os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		iop.Prn = gaddis.StreamOutput(f)
 	}
 
 	ec := &asm.ExecutionContext{
-		Rng: rand.New(rand.NewSource(seed)),
-		IoProvider: gaddis.IoAdapter{
-			In:      gaddis.StreamInput(streams.Stdin),
-			Out:     gaddis.StreamOutput(streams.Stdout),
-			WorkDir: ".",
-		},
+		Rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		Clock:      lib.SystemClock,
+		Args:       opts.args,
+		IoProvider: iop,
+	}
+
+	var traceOut *bufio.Writer
+	if opts.trace != "" {
+		// stderr is unbuffered, so each event shows up as it happens, interleaved with the program's output
+		var w io.Writer = os.Stderr
+		if opts.traceOut != "" {
+			f, err := os.Create(opts.traceOut)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			traceOut = bufio.NewWriter(f)
+			w = traceOut
+		}
+		tr, err := trace.New(w, src.desc(), src.src, opts.trace)
+		if err != nil {
+			return err
+		}
+		if opts.traceFuncs != "" {
+			tr.Funcs = map[string]bool{}
+			for _, name := range strings.Split(opts.traceFuncs, ",") {
+				tr.Funcs[strings.TrimSpace(name)] = true
+			}
+		}
+		ec.Hook = tr.Hook
 	}
 
 	p := assembled.NewExecution(ec)
-	if err := p.Run(); err != nil {
-		if streams.Silent {
-			_, _ = os.Stdout.Write(streams.Output.Bytes())
+	err := p.Run()
+	if traceOut != nil {
+		// flush before any exit
+		if err := traceOut.Flush(); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		var re *asm.RuntimeError
+		if errors.As(err, &re) {
+			_, _ = fmt.Fprint(os.Stderr, re.Render(src.desc(), src.src))
+		} else {
+			_, _ = fmt.Fprintln(os.Stderr, err)
 		}
-		_, _ = fmt.Fprintln(os.Stderr, err)
 		_, _ = fmt.Fprintln(os.Stderr, p.GetStackTrace(src.desc()))
 		os.Exit(1)
 	}
+	if p.ExitCode != 0 {
+		os.Exit(p.ExitCode)
+	}
 	return nil
 }
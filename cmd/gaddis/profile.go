@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
+	"github.com/dragonsinth/gaddis/lib"
+	"github.com/dragonsinth/gaddis/profile"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// profileCmd runs the program in the interpreter, then reports the instructions it executed to
+// stderr and writes a pprof profile.
+func profileCmd(args []string, opts runOpts, outFile string) error {
+	src, err := readSourceFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	prog, _, errs := gaddis.Compile(src.src)
+	reportErrors(errs, src.desc(), *fJson, os.Stdout)
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+
+	streams := runStreams(src)
+	pr := profile.New()
+	p := asmgen.Assemble(prog).NewExecution(&asm.ExecutionContext{
+		Rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		Clock: lib.SystemClock,
+		Args:  opts.args,
+		IoProvider: gaddis.IoAdapter{
+			In:      gaddis.StreamInput(streams.Stdin),
+			Out:     gaddis.StreamOutput(streams.Stdout),
+			WorkDir: ".",
+			Policy:  opts.inputPolicy,
+		},
+		Hook: pr.Hook,
+	})
+	runErr := p.Run()
+	pr.Finish()
+	if runErr != nil {
+		var re *asm.RuntimeError
+		if errors.As(runErr, &re) {
+			_, _ = fmt.Fprint(os.Stderr, re.Render(src.desc(), src.src))
+		} else {
+			_, _ = fmt.Fprintln(os.Stderr, runErr)
+		}
+	}
+
+	// the profile is still useful for a program that failed
+	_, _ = fmt.Fprintln(os.Stderr)
+	if err := pr.WriteListing(os.Stderr, src.src); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(os.Stderr)
+	if err := pr.WriteTable(os.Stderr); err != nil {
+		return err
+	}
+
+	if outFile == "" {
+		outFile = src.desc() + ".pprof"
+	}
+	var buf bytes.Buffer
+	if err := pr.WritePprof(&buf, src.desc()); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing to %s: %w", outFile, err)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "wrote %s; view with: go tool pprof -http=: %s\n", outFile, outFile)
+
+	if runErr != nil {
+		os.Exit(1)
+	}
+	if p.ExitCode != 0 {
+		os.Exit(p.ExitCode)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/lib"
+	"github.com/dragonsinth/gaddis/repl"
+	"golang.org/x/term"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	replPrompt         = "gaddis> "
+	replContinuePrompt = "   ...> "
+)
+
+const replHelp = `Enter declarations, statements, Modules, Functions, Classes, or an expression to evaluate.
+Blocks such as If ... End If continue until they are closed; enter a blank line to give up.
+  :type <expr>  show the type of an expression
+  :vars         list global variables and constants
+  :reset        forget everything
+  :help         show this help
+  :quit         exit (or Ctrl-D)
+`
+
+// replConsole reads entries and program input, with line editing and history on a terminal.
+type replConsole struct {
+	readLine func(prompt string) (string, error)
+	out      io.Writer
+}
+
+func replCmd(opts runOpts) error {
+	var con replConsole
+	var hook func(p *asm.Execution, inst asm.Inst)
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		t := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, "")
+
+		// Ctrl-C interrupts a running entry; any from before the line was read are stale
+		var interrupted atomic.Bool
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt)
+		defer signal.Stop(sigs)
+		go func() {
+			for range sigs {
+				interrupted.Store(true)
+			}
+		}()
+		hook = func(p *asm.Execution, inst asm.Inst) {
+			if interrupted.Swap(false) {
+				panic("interrupted")
+			}
+		}
+
+		con.readLine = func(prompt string) (string, error) {
+			// raw mode only while editing the line, so that Ctrl-C sends SIGINT while an entry runs
+			oldState, err := term.MakeRaw(fd)
+			if err != nil {
+				return "", err
+			}
+			defer func() { _ = term.Restore(fd, oldState) }()
+			t.SetPrompt(prompt)
+			line, err := t.ReadLine()
+			interrupted.Store(false)
+			return line, err
+		}
+	} else {
+		in := bufio.NewScanner(os.Stdin)
+		con.readLine = func(prompt string) (string, error) {
+			if !in.Scan() {
+				if err := in.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return in.Text(), nil
+		}
+	}
+	// output is only written between lines, when the terminal isn't raw
+	con.out = os.Stdout
+
+	s := repl.New(&asm.ExecutionContext{
+		Rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		Clock: lib.SystemClock,
+		Args:  opts.args,
+		IoProvider: gaddis.IoAdapter{
+			In:      func() (string, error) { return con.readLine("") },
+			Out:     gaddis.StreamOutput(con.out),
+			WorkDir: ".",
+			Policy:  opts.inputPolicy,
+		},
+		Hook: hook,
+	})
+
+	exitCode, err := con.run(s)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return exitError(exitCode)
+	}
+	return nil
+}
+
+// run reads and evaluates entries until end of input or exit, returning the exit code.
+func (con *replConsole) run(s *repl.Session) (int, error) {
+	_, _ = fmt.Fprintln(con.out, `Gaddis REPL; enter :help for help.`)
+	var pending []string
+	for {
+		prompt := replPrompt
+		if len(pending) > 0 {
+			prompt = replContinuePrompt
+		}
+		line, err := con.readLine(prompt)
+		if err == io.EOF {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		if len(pending) == 0 {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				continue
+			}
+			if strings.HasPrefix(trimmed, ":") {
+				if quit := con.command(s, trimmed); quit {
+					return 0, nil
+				}
+				continue
+			}
+		} else if strings.TrimSpace(line) == "" {
+			// give up on an unfinished entry, showing why it's unfinished
+			_, err := s.Eval(strings.Join(pending, "\n"))
+			con.printError(err)
+			pending = nil
+			continue
+		}
+
+		pending = append(pending, line)
+		res, err := s.Eval(strings.Join(pending, "\n"))
+		if errors.Is(err, repl.ErrIncomplete) {
+			continue
+		}
+		pending = nil
+		if err != nil {
+			con.printError(err)
+		} else if res.Exited {
+			return res.ExitCode, nil
+		} else if out := res.String(); out != "" {
+			_, _ = fmt.Fprintln(con.out, out)
+		}
+	}
+}
+
+// command runs a : command, returning true to quit.
+func (con *replConsole) command(s *repl.Session, line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case ":type", ":t":
+		if typ, err := s.TypeOf(arg); err != nil {
+			con.printError(err)
+		} else {
+			_, _ = fmt.Fprintln(con.out, typ)
+		}
+	case ":vars":
+		for _, v := range s.Vars() {
+			_, _ = fmt.Fprintln(con.out, v)
+		}
+	case ":reset":
+		s.Reset()
+	case ":help":
+		_, _ = fmt.Fprint(con.out, replHelp)
+	case ":quit", ":q":
+		return true
+	default:
+		_, _ = fmt.Fprintf(con.out, "unknown command %s; enter :help for help\n", cmd)
+	}
+	return false
+}
+
+func (con *replConsole) printError(err error) {
+	if err == nil {
+		return
+	}
+	var re *asm.RuntimeError
+	if errors.As(err, &re) {
+		_, _ = fmt.Fprintf(con.out, "runtime error: %v\n", err)
+		if hint := re.Kind.Hint(); hint != "" {
+			_, _ = fmt.Fprintf(con.out, "hint: %s\n", hint)
+		}
+		return
+	}
+	_, _ = fmt.Fprintln(con.out, strings.TrimPrefix(err.Error(), repl.ErrIncomplete.Error()+": "))
+}
+
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitError) ExitCode() int {
+	return int(e)
+}
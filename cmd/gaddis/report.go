@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// testReporter writes machine-readable test results.
+type testReporter func(w io.Writer, results []testResult, sum testSummary) error
+
+var testReporters = map[string]testReporter{
+	"json":  jsonReport,
+	"junit": junitReport,
+	"tap":   tapReport,
+}
+
+type testSummary struct {
+	passed, failed, errors int
+	elapsed                time.Duration
+}
+
+func summarize(results []testResult, elapsed time.Duration) testSummary {
+	sum := testSummary{elapsed: elapsed}
+	for _, r := range results {
+		switch r.status {
+		case testPass:
+			sum.passed++
+		case testFail:
+			sum.failed++
+		case testError:
+			sum.errors++
+		}
+	}
+	return sum
+}
+
+func (s testSummary) String() string {
+	return fmt.Sprintf("%d passed, %d failed, %d errors (%.2fs)", s.passed, s.failed, s.errors, s.elapsed.Seconds())
+}
+
+type jsonResult struct {
+	Name    string  `json:"name"`
+	File    string  `json:"file"`
+	Case    string  `json:"case,omitempty"`
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"` // seconds
+	Message string  `json:"message,omitempty"`
+	Error   string  `json:"error,omitempty"`
+	Diff    string  `json:"diff,omitempty"`
+	Stack   string  `json:"stack,omitempty"`
+}
+
+type jsonReportDoc struct {
+	Passed  int          `json:"passed"`
+	Failed  int          `json:"failed"`
+	Errors  int          `json:"errors"`
+	Elapsed float64      `json:"elapsed"` // seconds
+	Results []jsonResult `json:"results"`
+}
+
+func jsonReport(w io.Writer, results []testResult, sum testSummary) error {
+	doc := jsonReportDoc{
+		Passed:  sum.passed,
+		Failed:  sum.failed,
+		Errors:  sum.errors,
+		Elapsed: sum.elapsed.Seconds(),
+		Results: make([]jsonResult, 0, len(results)),
+	}
+	for _, r := range results {
+		jr := jsonResult{
+			Name:    r.tc.String(),
+			File:    r.tc.filename,
+			Case:    r.tc.name,
+			Status:  r.status.String(),
+			Elapsed: r.elapsed.Seconds(),
+			Diff:    r.diff,
+			Stack:   r.stack,
+		}
+		if r.status == testError {
+			jr.Error = r.detail
+		} else {
+			jr.Message = r.detail
+		}
+		doc.Results = append(doc.Results, jr)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// junitReport writes one test suite per program, with one test case per case.
+func junitReport(w io.Writer, results []testResult, _ testSummary) error {
+	var doc junitTestSuites
+	var suite *junitTestSuite
+	var suiteTime time.Duration
+	for _, r := range results {
+		if suite == nil || suite.Name != r.tc.filename {
+			if suite != nil {
+				suite.Time = seconds(suiteTime)
+			}
+			doc.Suites = append(doc.Suites, junitTestSuite{Name: r.tc.filename})
+			suite = &doc.Suites[len(doc.Suites)-1]
+			suiteTime = 0
+		}
+		suiteTime += r.elapsed
+
+		name := r.tc.name
+		if name == "" {
+			name = "default"
+		}
+		tc := junitTestCase{
+			Name:      name,
+			ClassName: r.tc.filename,
+			Time:      seconds(r.elapsed),
+		}
+		switch r.status {
+		case testFail:
+			suite.Failures++
+			tc.Failure = &junitProblem{Message: "wrong output", Type: "FAIL", Text: r.diff}
+		case testError:
+			suite.Errors++
+			msg, _, _ := strings.Cut(r.detail, "\n")
+			tc.Error = &junitProblem{Message: msg, Type: "ERROR", Text: joinNonEmpty(r.detail, r.stack)}
+		default:
+			tc.SystemOut = r.detail
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	if suite != nil {
+		suite.Time = seconds(suiteTime)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// tapReport writes version 13 of the Test Anything Protocol, with failure details as YAML.
+func tapReport(w io.Writer, results []testResult, sum testSummary) error {
+	var sb strings.Builder
+	sb.WriteString("TAP version 13\n")
+	_, _ = fmt.Fprintf(&sb, "1..%d\n", len(results))
+	for i, r := range results {
+		ok := "ok"
+		if r.status != testPass {
+			ok = "not ok"
+		}
+		_, _ = fmt.Fprintf(&sb, "%s %d - %s\n", ok, i+1, r.tc)
+		sb.WriteString("  ---\n")
+		_, _ = fmt.Fprintf(&sb, "  status: %s\n", r.status)
+		_, _ = fmt.Fprintf(&sb, "  duration_ms: %d\n", r.elapsed.Milliseconds())
+		if r.status == testError {
+			writeYamlBlock(&sb, "error", r.detail)
+		} else {
+			writeYamlBlock(&sb, "message", r.detail)
+		}
+		writeYamlBlock(&sb, "diff", r.diff)
+		writeYamlBlock(&sb, "stack", r.stack)
+		sb.WriteString("  ...\n")
+	}
+	_, _ = fmt.Fprintf(&sb, "# %s\n", sum)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeYamlBlock writes a YAML literal block scalar, if text is not empty.
+func writeYamlBlock(sb *strings.Builder, key string, text string) {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return
+	}
+	indicator := ""
+	if strings.HasPrefix(text, " ") {
+		// YAML infers the indentation from the first line, unless told
+		indicator = "2"
+	}
+	_, _ = fmt.Fprintf(sb, "  %s: |%s\n", key, indicator)
+	for _, line := range strings.Split(text, "\n") {
+		_, _ = fmt.Fprintf(sb, "    %s\n", line)
+	}
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func joinNonEmpty(parts ...string) string {
+	var ret []string
+	for _, p := range parts {
+		if p = strings.TrimRight(p, "\n"); p != "" {
+			ret = append(ret, p)
+		}
+	}
+	return strings.Join(ret, "\n")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"github.com/dragonsinth/gaddis/diff"
+	"testing"
+	"time"
+)
+
+// reportResults cover each status, markup that needs escaping, and multi-line errors and stacks.
+var reportResults = []testResult{
+	{tc: testCase{filename: "a.gad"}, status: testPass, elapsed: 1500 * time.Millisecond},
+	{tc: testCase{filename: "a.gad", name: "neg"}, status: testFail, elapsed: 20 * time.Millisecond,
+		diff: "--- a.gad.tests/neg.out\n+++ got\n@@ -1 +1 @@\n-<total> & \"more\"\n+<total> & 'less'\n"},
+	{tc: testCase{filename: "b.gad"}, status: testError, elapsed: 3 * time.Millisecond,
+		detail: "b.gad:2:10: index 2 out of range for array of size 2\n 2 | Display a[2]\n   |          ^^^\n",
+		stack:  "b.gad:2: in Module show()\nb.gad:5: in global\n"},
+	{tc: testCase{filename: "c.gad", name: "new"}, status: testPass, detail: "SAVED new test output"},
+	{tc: testCase{filename: "d.gad"}, status: testError, elapsed: time.Millisecond, detail: "  exit status 3\n"},
+}
+
+func TestReports(t *testing.T) {
+	sum := summarize(reportResults, 2*time.Second)
+	for _, tc := range []struct {
+		name string
+		want string
+	}{
+		{"json", jsonGolden},
+		{"junit", junitGolden},
+		{"tap", tapGolden},
+	} {
+		var buf bytes.Buffer
+		if err := testReporters[tc.name](&buf, reportResults, sum); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s report:\n%s", tc.name, diff.Unified("want", "got", tc.want, got, 3))
+		}
+	}
+}
+
+const jsonGolden = `{
+  "passed": 2,
+  "failed": 1,
+  "errors": 2,
+  "elapsed": 2,
+  "results": [
+    {
+      "name": "a.gad",
+      "file": "a.gad",
+      "status": "PASS",
+      "elapsed": 1.5
+    },
+    {
+      "name": "a.gad:neg",
+      "file": "a.gad",
+      "case": "neg",
+      "status": "FAIL",
+      "elapsed": 0.02,
+      "diff": "--- a.gad.tests/neg.out\n+++ got\n@@ -1 +1 @@\n-\u003ctotal\u003e \u0026 \"more\"\n+\u003ctotal\u003e \u0026 'less'\n"
+    },
+    {
+      "name": "b.gad",
+      "file": "b.gad",
+      "status": "ERROR",
+      "elapsed": 0.003,
+      "error": "b.gad:2:10: index 2 out of range for array of size 2\n 2 | Display a[2]\n   |          ^^^\n",
+      "stack": "b.gad:2: in Module show()\nb.gad:5: in global\n"
+    },
+    {
+      "name": "c.gad:new",
+      "file": "c.gad",
+      "case": "new",
+      "status": "PASS",
+      "elapsed": 0,
+      "message": "SAVED new test output"
+    },
+    {
+      "name": "d.gad",
+      "file": "d.gad",
+      "status": "ERROR",
+      "elapsed": 0.001,
+      "error": "  exit status 3\n"
+    }
+  ]
+}
+`
+
+const junitGolden = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="a.gad" tests="2" failures="1" errors="0" time="1.520">
+    <testcase name="default" classname="a.gad" time="1.500"></testcase>
+    <testcase name="neg" classname="a.gad" time="0.020">
+      <failure message="wrong output" type="FAIL">--- a.gad.tests/neg.out&#xA;+++ got&#xA;@@ -1 +1 @@&#xA;-&lt;total&gt; &amp; &#34;more&#34;&#xA;+&lt;total&gt; &amp; &#39;less&#39;&#xA;</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="b.gad" tests="1" failures="0" errors="1" time="0.003">
+    <testcase name="default" classname="b.gad" time="0.003">
+      <error message="b.gad:2:10: index 2 out of range for array of size 2" type="ERROR">b.gad:2:10: index 2 out of range for array of size 2&#xA; 2 | Display a[2]&#xA;   |          ^^^&#xA;b.gad:2: in Module show()&#xA;b.gad:5: in global</error>
+    </testcase>
+  </testsuite>
+  <testsuite name="c.gad" tests="1" failures="0" errors="0" time="0.000">
+    <testcase name="new" classname="c.gad" time="0.000">
+      <system-out>SAVED new test output</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="d.gad" tests="1" failures="0" errors="1" time="0.001">
+    <testcase name="default" classname="d.gad" time="0.001">
+      <error message="  exit status 3" type="ERROR">  exit status 3</error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+const tapGolden = `TAP version 13
+1..5
+ok 1 - a.gad
+  ---
+  status: PASS
+  duration_ms: 1500
+  ...
+not ok 2 - a.gad:neg
+  ---
+  status: FAIL
+  duration_ms: 20
+  diff: |
+    --- a.gad.tests/neg.out
+    +++ got
+    @@ -1 +1 @@
+    -<total> & "more"
+    +<total> & 'less'
+  ...
+not ok 3 - b.gad
+  ---
+  status: ERROR
+  duration_ms: 3
+  error: |
+    b.gad:2:10: index 2 out of range for array of size 2
+     2 | Display a[2]
+       |          ^^^
+  stack: |
+    b.gad:2: in Module show()
+    b.gad:5: in global
+  ...
+ok 4 - c.gad:new
+  ---
+  status: PASS
+  duration_ms: 0
+  message: |
+    SAVED new test output
+  ...
+not ok 5 - d.gad
+  ---
+  status: ERROR
+  duration_ms: 1
+  error: |2
+      exit status 3
+  ...
+# 2 passed, 1 failed, 2 errors (2.00s)
+`
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/lib"
 	"os"
 )
 
@@ -10,6 +12,14 @@ type runOpts struct {
 	stopAfterBuild    bool
 	leaveBuildOutputs bool
 	goGen             bool
+	spoolPrint        bool
+	args              []string // program arguments, after --
+	inputPolicy       lib.InputPolicy
+	updateGolden      bool   // test: rewrite mismatched .out and .print files
+	trace             string // run: trace modes, if any
+	traceOut          string // run: trace file; stderr if empty
+	traceFuncs        string // run: Modules and Functions to trace; all if empty
+	outFile           string // build: write bytecode to this file
 }
 
 func runCmd(args []string, opts runOpts) error {
@@ -32,10 +42,20 @@ func runCmd(args []string, opts runOpts) error {
 	}
 
 	streams := runStreams(src)
+	if opts.spoolPrint {
+		streams.PrintFile = src.desc() + ".print.txt"
+	}
+
+	if opts.trace != "" && opts.goGen {
+		return errors.New("-trace is not supported with -gogen")
+	}
+	if opts.outFile != "" && opts.goGen {
+		return errors.New("-o is not supported with -gogen")
+	}
 
 	if !opts.goGen {
-		return runInterp(src, opts, false, streams, prog)
+		return runInterp(src, opts, streams, prog)
 	} else {
-		return runGo(src, opts, false, streams, prog)
+		return runGo(src, opts, streams, prog)
 	}
 }
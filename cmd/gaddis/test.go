@@ -1,85 +1,71 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"github.com/dragonsinth/gaddis"
 	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
 )
 
 func test(args []string, opts runOpts) error {
-	src, err := readSourceFromArgs(args)
-	if err != nil {
-		return err
-	}
-
-	prog, outSrc, errs := gaddis.Compile(src.src)
-	reportErrors(errs, src.desc(), *fJson, os.Stdout)
-	if len(errs) > 0 {
-		os.Exit(1)
-	}
-
-	// auto format on success only
-	if !src.isStdin && src.src != outSrc {
-		if err = os.WriteFile(src.filename, []byte(outSrc), 0666); err != nil {
-			return fmt.Errorf("writing to %s: %w", src.filename, err)
+	var report testReporter
+	if *fReport != "" {
+		if report = testReporters[*fReport]; report == nil {
+			return fmt.Errorf("unknown -report format: %s", *fReport)
 		}
 	}
-
-	outFile := src.desc() + ".out"
-	isCaptureMode := false
-	wantOutput, err := os.ReadFile(outFile)
-	if os.IsNotExist(err) {
-		isCaptureMode = true
-	} else if err != nil {
-		return fmt.Errorf("reading %s: %w", outFile, err)
-	}
-
-	if isCaptureMode {
-		fmt.Println("Capturing...")
-	}
-
-	var streams *procStreams
-	if isCaptureMode {
-		streams = captureStreams(src)
-	} else {
-		streams = testStreams(src)
+	if isTestPattern(args) || report != nil {
+		filter, err := regexp.Compile(*fRun)
+		if err != nil {
+			return fmt.Errorf("bad -run pattern: %w", err)
+		}
+		return testPatterns(args, opts, filter, *fParallel, report)
 	}
 
-	if !opts.goGen {
-		err = runInterp(src, opts, true, streams, prog)
-	} else {
-		err = runGo(src, opts, true, streams, prog)
+	// a single program, from stdin or a file with an optional case name, which may be captured interactively;
+	// like run, it runs from the current directory
+	var caseName string
+	if len(args) == 1 {
+		var filename string
+		filename, caseName = splitCaseArg(args[0])
+		args = []string{filename}
 	}
+	src, err := readSourceFromArgs(args)
 	if err != nil {
 		return err
 	}
+	tc := testCase{filename: src.desc(), name: caseName}
+	isCaptureMode := !tc.hasOutput()
 
-	gotOutput := streams.Output.Bytes()
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	tf := testFile{filename: src.desc(), cases: []testCase{tc}, src: src, workDir: ".", interactive: true}
+	r := runTestFile(ctx, tf, opts)[0]
 
-	// if we were running capture mode and captured any input, dump it to an input file
-	if isCaptureMode {
-		// drop the captured input into an input file
-		if streams.Input.Len() > 0 {
-			inFile := src.desc() + ".in"
-			if err := os.WriteFile(inFile, streams.Input.Bytes(), 0644); err != nil {
-				return fmt.Errorf("writing to %s: %w", inFile, err)
-			}
+	switch r.status {
+	case testPass:
+		fmt.Print(r.detail)
+		if isCaptureMode {
+			fmt.Println()
+		} else {
+			fmt.Println("PASSED")
 		}
-		// create an output file
-		if err := os.WriteFile(outFile, gotOutput, 0644); err != nil {
-			return fmt.Errorf("writing to %s: %w", outFile, err)
+	case testFail:
+		_, _ = fmt.Fprint(os.Stderr, "wrong output:\n"+r.diff)
+		os.Exit(1)
+	case testError:
+		if !isCaptureMode {
+			// capturing already showed it
+			fmt.Print(r.output)
 		}
-		fmt.Println("SAVED new test output")
-	} else {
-		// compare the output instead
-		if !bytes.Equal(gotOutput, wantOutput) {
-			// compare the output
-			_, _ = fmt.Fprintf(os.Stderr, "wrong output: got=\n%s\nwant=\n%s\n", gotOutput, wantOutput)
-			os.Exit(1)
+		_, _ = fmt.Fprintln(os.Stderr, strings.TrimRight(r.detail, "\n"))
+		if r.stack != "" {
+			_, _ = fmt.Fprintln(os.Stderr, r.stack)
 		}
-		fmt.Println("PASSED")
+		os.Exit(1)
 	}
-
 	return nil
 }
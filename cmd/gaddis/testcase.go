@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// testCasesDir is the suffix of the directory holding a program's named test cases;
+// each case is a set of files <case>.in, <case>.out and <case>.print, like the default case.
+const testCasesDir = ".tests"
+
+// testCase is one set of input and expected output files for a program.
+type testCase struct {
+	filename string // the program
+	name     string // empty for the default case
+}
+
+// prefix returns the path the case's .in, .out and .print files are named after.
+func (tc testCase) prefix() string {
+	if tc.name == "" {
+		return tc.filename
+	}
+	return filepath.Join(tc.filename+testCasesDir, tc.name)
+}
+
+func (tc testCase) String() string {
+	if tc.name == "" {
+		return tc.filename
+	}
+	return tc.filename + ":" + tc.name
+}
+
+// hasOutput reports whether the case has expected output; if not, running it captures output.
+func (tc testCase) hasOutput() bool {
+	_, err := os.Stat(tc.prefix() + ".out")
+	return err == nil
+}
+
+// splitCaseArg splits a "file.gad:case" argument into the program and case name.
+func splitCaseArg(arg string) (string, string) {
+	if i := strings.LastIndexByte(arg, ':'); i >= 0 && strings.HasSuffix(arg[:i], ".gad") {
+		return arg[:i], arg[i+1:]
+	}
+	return arg, ""
+}
+
+// findTestCases returns the default case, if it has a .out file, followed by the named cases.
+// A named case with only a .in file is included, so that its output can be captured.
+func findTestCases(filename string) []testCase {
+	var ret []testCase
+	if tc := (testCase{filename: filename}); tc.hasOutput() {
+		ret = append(ret, tc)
+	}
+
+	entries, _ := os.ReadDir(filename + testCasesDir)
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		ext := filepath.Ext(name)
+		if e.IsDir() || (ext != ".in" && ext != ".out") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(name, ext))
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		ret = append(ret, testCase{filename: filename, name: name})
+	}
+	return ret
+}
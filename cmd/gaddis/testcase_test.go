@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestSplitCaseArg(t *testing.T) {
+	for _, tc := range []struct {
+		arg        string
+		file, name string
+	}{
+		{"prog.gad", "prog.gad", ""},
+		{"prog.gad:negative", "prog.gad", "negative"},
+		{"dir/prog.gad:a:b", "dir/prog.gad:a:b", ""},
+		{"c:/dir/prog.gad", "c:/dir/prog.gad", ""},
+		{"c:/dir/prog.gad:x", "c:/dir/prog.gad", "x"},
+		{"dir:x", "dir:x", ""},
+		{"./...", "./...", ""},
+	} {
+		file, name := splitCaseArg(tc.arg)
+		if file != tc.file || name != tc.name {
+			t.Errorf("splitCaseArg(%q) = %q, %q; want %q, %q", tc.arg, file, name, tc.file, tc.name)
+		}
+	}
+}
+
+// makeTestTree creates the given files, all empty, under a temp dir which becomes the working directory.
+func makeTestTree(t *testing.T, files ...string) {
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+var testTree = []string{
+	"a.gad", "a.gad.out",
+	"a.gad.tests/big.in", "a.gad.tests/big.out",
+	"a.gad.tests/new.in", // only input: captured on the next run
+	"a.gad.tests/notes.txt",
+	"b.gad", // no cases at all
+	"c.gad", "c.gad.tests/only.in", "c.gad.tests/only.out",
+	"sub/d.gad", "sub/d.gad.in", "sub/d.gad.out",
+	"sub/deeper/e.gad", "sub/deeper/e.gad.out",
+}
+
+func TestFindTestCases(t *testing.T) {
+	makeTestTree(t, testTree...)
+	for _, tc := range []struct {
+		file string
+		want []string
+	}{
+		{"a.gad", []string{"a.gad", "a.gad:big", "a.gad:new"}},
+		{"b.gad", nil},
+		{"c.gad", []string{"c.gad:only"}},
+		{"sub/d.gad", []string{"sub/d.gad"}},
+	} {
+		var got []string
+		for _, c := range findTestCases(tc.file) {
+			got = append(got, c.String())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("findTestCases(%s) = %q, want %q", tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestFindTestFiles(t *testing.T) {
+	makeTestTree(t, testTree...)
+	for _, tc := range []struct {
+		name     string
+		patterns []string
+		filter   string
+		want     []string
+	}{
+		{"dir", []string{"."}, "", []string{"a.gad", "a.gad:big", "a.gad:new", "c.gad:only"}},
+		{"recursive", []string{"./..."}, "", []string{"a.gad", "a.gad:big", "a.gad:new", "c.gad:only", "sub/d.gad", "sub/deeper/e.gad"}},
+		{"subdir", []string{"sub/..."}, "", []string{"sub/d.gad", "sub/deeper/e.gad"}},
+		{"file", []string{"a.gad"}, "", []string{"a.gad", "a.gad:big", "a.gad:new"}},
+		{"file without cases", []string{"b.gad"}, "", []string{"b.gad"}},
+		{"case", []string{"a.gad:big"}, "", []string{"a.gad:big"}},
+		{"new case", []string{"a.gad:other"}, "", []string{"a.gad:other"}},
+		{"duplicates", []string{"c.gad", "."}, "", []string{"a.gad", "a.gad:big", "a.gad:new", "c.gad:only"}},
+		{"filter", []string{"./..."}, ":big|deeper", []string{"a.gad:big", "sub/deeper/e.gad"}},
+	} {
+		var filter *regexp.Regexp
+		if tc.filter != "" {
+			filter = regexp.MustCompile(tc.filter)
+		}
+		files, err := findTestFiles(tc.patterns, filter)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var got []string
+		for _, f := range files {
+			for _, c := range f.cases {
+				got = append(got, filepath.ToSlash(c.String()))
+			}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	for _, patterns := range [][]string{{"missing.gad"}, {"a.gad/..."}} {
+		if _, err := findTestFiles(patterns, nil); err == nil {
+			t.Errorf("%q: expected an error", patterns)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/diff"
+	"github.com/dragonsinth/gaddis/goexec"
+	"github.com/dragonsinth/gaddis/gogen"
+	"github.com/dragonsinth/gaddis/lib"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type testStatus int
+
+const (
+	testPass testStatus = iota
+	testFail
+	testError
+)
+
+var testStatusNames = [...]string{"PASS", "FAIL", "ERROR"}
+
+func (s testStatus) String() string {
+	return testStatusNames[s]
+}
+
+type testResult struct {
+	tc      testCase
+	status  testStatus
+	elapsed time.Duration
+	detail  string // why the test errored, or what it saved
+	diff    string // how the output differed from the .out or .print file
+	stack   string // the Gaddis stack trace, if the program crashed
+	output  string // what the program wrote before it crashed
+}
+
+// testCrash is a runtime error in a program under test.
+type testCrash struct {
+	report string
+	stack  string
+}
+
+func (e *testCrash) Error() string {
+	return e.report
+}
+
+// isTestPattern reports whether args name more than a single test case.
+func isTestPattern(args []string) bool {
+	if len(args) > 1 {
+		return true
+	}
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "...") {
+			return true
+		}
+		if fi, err := os.Stat(arg); err == nil && fi.IsDir() {
+			return true
+		}
+		if fi, err := os.Stat(arg + testCasesDir); err == nil && fi.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// testFile is a program and the test cases to run it with.
+type testFile struct {
+	filename    string
+	cases       []testCase
+	src         *source // the program, if already read; e.g. from stdin
+	workDir     string  // where the program runs; its own directory if empty
+	interactive bool    // capture a case without input or output from the terminal
+}
+
+// testPatterns runs every test case matched by patterns in parallel, reporting each result in order.
+// If report is set, it writes the results instead.
+func testPatterns(patterns []string, opts runOpts, filter *regexp.Regexp, parallel int, report testReporter) error {
+	files, err := findTestFiles(patterns, filter)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no test files found")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// each file's cases run in sequence, so they can share one build
+	start := time.Now()
+	results := make([][]testResult, len(files))
+	done := make([]chan struct{}, len(files))
+	sem := make(chan struct{}, max(1, parallel))
+	for i, tf := range files {
+		done[i] = make(chan struct{})
+		go func() {
+			defer close(done[i])
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = runTestFile(ctx, tf, opts)
+		}()
+	}
+
+	var all []testResult
+	for i := range files {
+		<-done[i]
+		for _, r := range results[i] {
+			all = append(all, r)
+			if report == nil {
+				printTestResult(r)
+			}
+		}
+	}
+
+	sum := summarize(all, time.Since(start))
+	if report != nil {
+		if err := report(os.Stdout, all, sum); err != nil {
+			return err
+		}
+	}
+	if sum.passed != len(all) {
+		return errors.New("FAIL: " + sum.String())
+	}
+	if report == nil {
+		fmt.Println("ok: " + sum.String())
+	}
+	return nil
+}
+
+func printTestResult(r testResult) {
+	fmt.Printf("%-5s %s (%.2fs)\n", r.status, r.tc, r.elapsed.Seconds())
+	text := r.detail
+	if r.diff != "" {
+		text += "wrong output:\n" + r.diff
+	}
+	if r.stack != "" {
+		text += "\n" + r.stack
+	}
+	if text = strings.TrimRight(text, "\n"); text != "" {
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+}
+
+// findTestFiles expands patterns into the sorted list of .gad files that have test cases.
+// A pattern is a file, optionally followed by ":case" to run a single case; a directory;
+// or a directory followed by "/..." to include subdirectories.
+// The filter selects cases by name, e.g. "dir/prog.gad" or "dir/prog.gad:case".
+func findTestFiles(patterns []string, filter *regexp.Regexp) ([]testFile, error) {
+	var files []testFile
+	add := func(filename string, cases []testCase) {
+		cases = slices.DeleteFunc(cases, func(tc testCase) bool {
+			return filter != nil && !filter.MatchString(filepath.ToSlash(tc.String()))
+		})
+		if len(cases) > 0 {
+			files = append(files, testFile{filename: filename, cases: cases})
+		}
+	}
+	for _, pattern := range patterns {
+		recursive := strings.HasSuffix(pattern, "...")
+		root, caseName := splitCaseArg(filepath.Clean(strings.TrimSuffix(pattern, "...")))
+		fi, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			if recursive {
+				return nil, fmt.Errorf("%s: not a directory", root)
+			}
+			// named explicitly; a missing .out file is reported as an error
+			cases := findTestCases(root)
+			if caseName != "" {
+				cases = []testCase{{filename: root, name: caseName}}
+			} else if len(cases) == 0 {
+				cases = []testCase{{filename: root}}
+			}
+			add(root, cases)
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && (!recursive || strings.HasSuffix(path, testCasesDir)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".gad") {
+				add(path, findTestCases(path))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.SortStableFunc(files, func(a, b testFile) int {
+		return strings.Compare(a.filename, b.filename)
+	})
+	return slices.CompactFunc(files, func(a, b testFile) bool {
+		return a.filename == b.filename
+	}), nil
+}
+
+// testRunner runs a compiled program once with the given input, writing Print output to printFile if set.
+type testRunner func(input io.Reader, output io.Writer, printFile string) error
+
+// runTestFile compiles a program once, then runs each of its test cases.
+func runTestFile(ctx context.Context, tf testFile, opts runOpts) []testResult {
+	start := time.Now()
+	fail := func(format string, args ...any) []testResult {
+		return []testResult{{
+			tc:      testCase{filename: tf.filename},
+			status:  testError,
+			elapsed: time.Since(start),
+			detail:  fmt.Sprintf(format, args...),
+		}}
+	}
+
+	src := tf.src
+	if src == nil {
+		srcBytes, err := os.ReadFile(tf.filename)
+		if err != nil {
+			return fail("%v", err)
+		}
+		src = &source{src: string(srcBytes), filename: tf.filename}
+	}
+	workDir := tf.workDir
+	if workDir == "" {
+		workDir = filepath.Dir(tf.filename)
+	}
+
+	prog, outSrc, errs := gaddis.Compile(src.src)
+	if len(errs) > 0 {
+		var sb strings.Builder
+		reportErrors(errs, src.desc(), false, &sb)
+		return fail("%s", sb.String())
+	}
+
+	// auto format on success only
+	if !src.isStdin && src.src != outSrc {
+		if err := os.WriteFile(tf.filename, []byte(outSrc), 0666); err != nil {
+			return fail("writing to %s: %v", tf.filename, err)
+		}
+	}
+
+	var run testRunner
+	if !opts.goGen {
+		run = interpTestRunner(src, workDir, opts, prog)
+	} else {
+		br, err := goexec.Build(ctx, gogen.GoGenerate(prog, true), src.desc())
+		defer func() {
+			if !opts.leaveBuildOutputs {
+				if br.GoFile != "" {
+					_ = os.Remove(br.GoFile)
+				}
+				if br.ExeFile != "" {
+					_ = os.Remove(br.ExeFile)
+				}
+			}
+		}()
+		if err != nil {
+			return fail("building %s: %v", src.desc(), err)
+		}
+		run = goTestRunner(ctx, workDir, opts, br.ExeFile)
+	}
+
+	var ret []testResult
+	for i, tc := range tf.cases {
+		if i > 0 {
+			start = time.Now()
+		}
+		r := runTestCase(tc, run, opts.updateGolden, tf.interactive)
+		r.elapsed = time.Since(start)
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// runTestCase runs a single test case, comparing its output with the .out file,
+// or capturing the output if the case only has input so far. If update is set,
+// mismatched golden files are rewritten from the actual output. If interactive is set,
+// a case without input captures it from the terminal, showing the program as it runs.
+func runTestCase(tc testCase, run testRunner, update bool, interactive bool) testResult {
+	ret := testResult{tc: tc}
+	fail := func(status testStatus, format string, args ...any) testResult {
+		ret.status = status
+		ret.detail = fmt.Sprintf(format, args...)
+		return ret
+	}
+
+	prefix := tc.prefix()
+	input, err := os.ReadFile(prefix + ".in")
+	hasInput := err == nil
+	isCaptureMode := !tc.hasOutput()
+	if isCaptureMode && !hasInput && !interactive {
+		// without input either, there is nothing to capture from
+		return fail(testError, "missing %s", prefix+".out")
+	}
+	wantOutput, _ := os.ReadFile(prefix + ".out")
+	wantPrint, err := os.ReadFile(prefix + ".print")
+	hasPrint := err == nil
+
+	var printFile string
+	if isCaptureMode || hasPrint {
+		tmp, err := os.CreateTemp("", "gaddis-print-*.txt")
+		if err != nil {
+			return fail(testError, "%v", err)
+		}
+		_ = tmp.Close()
+		defer func() { _ = os.Remove(tmp.Name()) }()
+		printFile = tmp.Name()
+	}
+
+	var output, capturedInput bytes.Buffer
+	var in io.Reader = bytes.NewReader(input)
+	var out io.Writer = &output
+	if isCaptureMode && interactive {
+		fmt.Println("Capturing...")
+		out = io.MultiWriter(stdoutSyncWriter{}, &output)
+		if !hasInput {
+			in = io.TeeReader(os.Stdin, &capturedInput)
+		}
+	}
+	if err := run(in, out, printFile); err != nil {
+		var crash *testCrash
+		if errors.As(err, &crash) {
+			ret.stack = crash.stack
+		}
+		ret.output = output.String()
+		return fail(testError, "%v", err)
+	}
+	var gotPrint []byte
+	if printFile != "" {
+		if gotPrint, err = os.ReadFile(printFile); err != nil {
+			return fail(testError, "%v", err)
+		}
+	}
+
+	if isCaptureMode {
+		// a new named case needs its directory
+		if err := os.MkdirAll(filepath.Dir(prefix), 0755); err != nil {
+			return fail(testError, "%v", err)
+		}
+		if capturedInput.Len() > 0 {
+			if err := os.WriteFile(prefix+".in", capturedInput.Bytes(), 0644); err != nil {
+				return fail(testError, "writing to %s: %v", prefix+".in", err)
+			}
+		}
+		if err := os.WriteFile(prefix+".out", output.Bytes(), 0644); err != nil {
+			return fail(testError, "writing to %s: %v", prefix+".out", err)
+		}
+		if len(gotPrint) > 0 {
+			if err := os.WriteFile(prefix+".print", gotPrint, 0644); err != nil {
+				return fail(testError, "writing to %s: %v", prefix+".print", err)
+			}
+		}
+		ret.detail = "SAVED new test output"
+		return ret
+	}
+
+	check := func(file string, want, got []byte) bool {
+		msg, ok, err := checkGolden(file, want, got, update)
+		switch {
+		case err != nil:
+			ret.status = testError
+			ret.detail += err.Error() + "\n"
+		case !ok:
+			ret.status = testFail
+			ret.diff += msg
+		default:
+			ret.detail += msg
+		}
+		return err == nil
+	}
+	if check(prefix+".out", wantOutput, output.Bytes()) && hasPrint {
+		check(prefix+".print", wantPrint, gotPrint)
+	}
+	return ret
+}
+
+// checkGolden compares got with the expected contents of a golden file. On a mismatch,
+// it returns a diff, or if update is set, rewrites the file and says so.
+func checkGolden(file string, want, got []byte, update bool) (string, bool, error) {
+	if bytes.Equal(got, want) {
+		return "", true, nil
+	}
+	if update {
+		if err := os.WriteFile(file, got, 0644); err != nil {
+			return "", false, fmt.Errorf("writing to %s: %w", file, err)
+		}
+		return "UPDATED " + file + "\n", true, nil
+	}
+	return diff.Unified(file, "got", string(want), string(got), 3), false, nil
+}
+
+func interpTestRunner(src *source, workDir string, opts runOpts, prog *ast.Program) testRunner {
+	assembled := asmgen.Assemble(prog)
+	return func(input io.Reader, output io.Writer, printFile string) error {
+		iop := gaddis.IoAdapter{
+			In:      gaddis.StreamInput(input),
+			Out:     gaddis.StreamOutput(output),
+			WorkDir: workDir,
+			Policy:  opts.inputPolicy,
+		}
+		if printFile != "" {
+			f, err := os.OpenFile(printFile, os.O_WRONLY|os.O_APPEND, 0666)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			iop.Prn = gaddis.StreamOutput(f)
+		}
+
+		p := assembled.NewExecution(&asm.ExecutionContext{
+			Rng:        rand.New(rand.NewSource(0)),
+			Clock:      lib.NewFakeClock(),
+			Args:       opts.args,
+			IoProvider: iop,
+		})
+		if err := p.Run(); err != nil {
+			crash := &testCrash{report: err.Error(), stack: p.GetStackTrace(src.desc())}
+			var re *asm.RuntimeError
+			if errors.As(err, &re) {
+				crash.report = re.Render(src.desc(), src.src)
+			}
+			return crash
+		}
+		if p.ExitCode != 0 {
+			return fmt.Errorf("exit status %d", p.ExitCode)
+		}
+		return nil
+	}
+}
+
+func goTestRunner(ctx context.Context, workDir string, opts runOpts, exeFile string) testRunner {
+	return func(input io.Reader, output io.Writer, printFile string) error {
+		env := lib.InputPolicyEnv(opts.inputPolicy)
+		if printFile != "" {
+			env = append(env, lib.PrintEnv+"="+printFile)
+		}
+		var errput bytes.Buffer
+		err := goexec.Run(ctx, workDir, env, exeFile, opts.args, input, output, &errput)
+		if err != nil {
+			return fmt.Errorf("%w\n%s", err, errput.String())
+		}
+		if errput.Len() > 0 {
+			return errors.New(errput.String())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a program which reads data.txt, then crashes if it says so
+const readDataProg = `Declare String s
+Declare Integer z = 0
+Declare InputFile f
+Open f "data.txt"
+Read f s
+Close f
+Display s
+If s == "crash" Then
+    Display 1 / z
+End If
+`
+
+func TestRunTestFileWorkDir(t *testing.T) {
+	makeTestTree(t)
+	for name, content := range map[string]string{
+		"sub/prog.gad":     readDataProg,
+		"sub/prog.gad.out": "sub\n",
+		"sub/data.txt":     "\"sub\"\n",
+		"data.txt":         "\"top\"\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	// a pattern runs each program from its own directory
+	files, err := findTestFiles([]string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	if r := runTestFile(ctx, files[0], runOpts{})[0]; r.status != testPass {
+		t.Errorf("pattern: got %s: %s%s", r.status, r.detail, r.diff)
+	}
+
+	// a single file runs from the current directory, like run
+	tf := testFile{filename: "sub/prog.gad", cases: []testCase{{filename: "sub/prog.gad"}}, workDir: "."}
+	if r := runTestFile(ctx, tf, runOpts{}); r[0].status != testFail || r[0].diff == "" {
+		t.Errorf("single file: got %s, want a diff against the top data", r[0].status)
+	}
+
+	// a crash keeps what the program wrote first
+	if err := os.WriteFile("data.txt", []byte("\"crash\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := runTestFile(ctx, tf, runOpts{})[0]
+	if r.status != testError || r.output != "crash\n" {
+		t.Errorf("crash: got %s with output %q", r.status, r.output)
+	}
+}
@@ -8,9 +8,18 @@ import (
 // Collect constructs scopes, collects global symbols.
 // We need to do this in two passes to correctly chain super scopes.
 func Collect(prog *ast.Program) []ast.Error {
-	prog.Scope = ast.NewGlobalScope(prog.Block)
+	return CollectInto(prog, ast.NewGlobalScope(prog.Block))
+}
+
+// CollectInto is like Collect, but adds the program's symbols to an existing global scope.
+// Used by the REPL, where each entry is compiled as a separate program.
+func CollectInto(prog *ast.Program, globalScope *ast.Scope) []ast.Error {
+	prog.Scope = globalScope
 
 	cc := &ClassCollector{classes: map[string]*ast.ClassStmt{}}
+	for _, cs := range globalScope.Classes {
+		cc.classes[cs.Name] = cs
+	}
 	prog.Visit(cc)
 	for _, stmt := range cc.classes {
 		createClassScope(cc.classes, stmt, prog.Scope)
@@ -27,9 +36,11 @@ func createClassScope(classes map[string]*ast.ClassStmt, stmt *ast.ClassStmt, gl
 	}
 	parentScope := globalScope
 	if stmt.Extends != "" {
-		parent := classes[stmt.Extends]
-		createClassScope(classes, parent, globalScope)
-		parentScope = parent.Scope
+		// an undefined parent is reported as an unresolved type
+		if parent := classes[stmt.Extends]; parent != nil {
+			createClassScope(classes, parent, globalScope)
+			parentScope = parent.Scope
+		}
 	}
 	stmt.Scope = ast.NewClassScope(stmt, parentScope)
 	stmt.Type.Class = stmt
@@ -0,0 +1,15 @@
+package collect_test
+
+import (
+	"github.com/dragonsinth/gaddis"
+	"testing"
+)
+
+func TestUndefinedParentClass(t *testing.T) {
+	// must report the missing class rather than crash building the scope chain
+	const src = "Class B Extends Missing\n  Public Integer v\nEnd Class\n"
+	_, _, errs := gaddis.Compile(src)
+	if len(errs) != 1 || errs[0].Desc != "undefined type Missing" {
+		t.Errorf("got %v, want undefined type Missing", errs)
+	}
+}
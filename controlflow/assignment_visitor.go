@@ -152,18 +152,18 @@ func (v *AssignmentVisitor) PostVisitVariableExpr(ve *ast.VariableExpr) {
 }
 
 func (v *AssignmentVisitor) PreVisitCallExpr(ce *ast.CallExpr) bool {
-	for i, arg := range ce.Args {
-		if ce.Ref.Params[i].IsRef {
-			v.pendingWrite(arg)
+	for i, param := range ce.Ref.Params {
+		if param.IsRef {
+			v.pendingWrite(ce.Args[i])
 		}
 	}
 	return true
 }
 
 func (v *AssignmentVisitor) PostVisitCallExpr(ce *ast.CallExpr) {
-	for i, arg := range ce.Args {
-		if ce.Ref.Params[i].IsRef {
-			v.finishWrite(arg)
+	for i, param := range ce.Ref.Params {
+		if param.IsRef {
+			v.finishWrite(ce.Args[i])
 		}
 	}
 }
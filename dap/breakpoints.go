@@ -1,6 +1,7 @@
 package dap
 
 import (
+	"errors"
 	"github.com/dragonsinth/gaddis/asm"
 	"github.com/dragonsinth/gaddis/debug"
 	api "github.com/google/go-dap"
@@ -169,6 +170,12 @@ func (h *Session) onExceptionInfoRequest(request *api.ExceptionInfoRequest) {
 			Message:    msg,
 			StackTrace: trace,
 		}
+		var re *asm.RuntimeError
+		if errors.As(exception, &re) {
+			response.Body.ExceptionId = re.Kind.String()
+			response.Body.Description = re.Render(h.sess.Source.Path, h.sess.Source.Src)
+			response.Body.Details.TypeName = re.Kind.String()
+		}
 	}
 	h.send(response)
 }
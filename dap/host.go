@@ -17,6 +17,8 @@ type eventHost struct {
 	remainingInput <-chan string
 	capturedOutput strings.Builder
 	wantOutput     string
+	capturedPrint  strings.Builder
+	wantPrint      *string // nil if Print output is not checked
 	isTest         bool
 
 	// copy variables from session to avoid memory races
@@ -104,6 +106,7 @@ func (eh *eventHost) Exited(code int) {
 	if eh.isTest && code == 0 {
 		// check the output!
 		gotOutput := eh.capturedOutput.String()
+		gotPrint := eh.capturedPrint.String()
 		if eh.wantOutput != gotOutput {
 			code = 1
 			msg := fmt.Sprintf(failFmt, eh.wantOutput, gotOutput)
@@ -111,6 +114,13 @@ func (eh *eventHost) Exited(code int) {
 				Event: *newEvent("output"),
 				Body:  api.OutputEventBody{Category: "stderr", Output: msg, Source: eh.source},
 			})
+		} else if eh.wantPrint != nil && *eh.wantPrint != gotPrint {
+			code = 1
+			msg := "\nPrint output:" + fmt.Sprintf(failFmt, *eh.wantPrint, gotPrint)
+			eh.send(&api.OutputEvent{
+				Event: *newEvent("output"),
+				Body:  api.OutputEventBody{Category: "stderr", Output: msg, Source: eh.source},
+			})
 		} else if rem, err := eh.drainStdin(); rem != "" || err != nil {
 			code = 2
 			failLine := "not all input was read"
@@ -164,6 +164,18 @@ func (h *Session) stdout(line string) {
 	})
 }
 
+// printer sends printer output in its own category, so the client can show it separately.
+func (h *Session) printer(line string) {
+	h.send(&api.OutputEvent{
+		Event: *newEvent("output"),
+		Body: api.OutputEventBody{
+			Category: "printer",
+			Output:   line,
+			Source:   h.source,
+		},
+	})
+}
+
 func (h *Session) pausedSessionRequiredError(request api.RequestMessage) bool {
 	if h.sess == nil {
 		h.send(newErrorResponse(request.GetSeq(), request.GetRequest().Command, "no session found"))
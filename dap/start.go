@@ -3,6 +3,7 @@ package dap
 import (
 	"fmt"
 	"github.com/dragonsinth/gaddis/debug"
+	"github.com/dragonsinth/gaddis/lib"
 	api "github.com/google/go-dap"
 	"log"
 	"os"
@@ -35,6 +36,18 @@ func (h *Session) tryStartSession(args launchArgs, request *api.Request) bool {
 		return false
 	}
 
+	inputMode := lib.DefaultInput
+	if args.Input != "" {
+		var ok bool
+		if inputMode, ok = lib.ParseInputMode(args.Input); !ok {
+			h.send(newErrorResponse(request.Seq, request.Command, "unknown input mode: "+args.Input))
+			return false
+		}
+	}
+	if args.Prompt != "" && inputMode == lib.DefaultInput {
+		inputMode = lib.CustomPrompt // prompt implies custom
+	}
+
 	h.runId++
 
 	host := eventHost{
@@ -53,6 +66,7 @@ func (h *Session) tryStartSession(args launchArgs, request *api.Request) bool {
 		stdin = ch
 	}
 	stdout := h.stdout
+	printer := h.printer
 
 	if args.TestMode {
 		outfile := args.Program + ".out"
@@ -70,6 +84,19 @@ func (h *Session) tryStartSession(args launchArgs, request *api.Request) bool {
 			host.capturedOutput.WriteString(line)
 			h.stdout(line)
 		}
+		if wantPrint, err := os.ReadFile(args.Program + ".print"); err == nil {
+			host.wantPrint = new(string)
+			*host.wantPrint = string(wantPrint)
+		}
+		printer = func(line string) {
+			if host.wantPrint == nil {
+				// unchecked Print output is part of the regular output
+				stdout(line)
+				return
+			}
+			host.capturedPrint.WriteString(line)
+			h.printer(line)
+		}
 	} else {
 		if h.terminal == nil && h.canTerminal {
 			title := "Gaddis Debug " + args.Name
@@ -114,7 +141,10 @@ func (h *Session) tryStartSession(args launchArgs, request *api.Request) bool {
 	opts := debug.Opts{
 		Input:       stdin,
 		Output:      stdout,
+		Print:       printer,
 		WorkDir:     args.WorkDir,
+		Args:        args.Args,
+		InputPolicy: lib.InputPolicy{Mode: inputMode, Prompt: args.Prompt, Echo: args.Transcript},
 		IsTest:      args.TestMode,
 		NoDebug:     args.NoDebug,
 		StopOnEntry: args.StopOnEntry,
@@ -9,12 +9,16 @@ import (
 )
 
 type launchArgs struct {
-	Name        string `json:"name"`
-	Program     string `json:"program"`
-	WorkDir     string `json:"workDir"`
-	TestMode    bool   `json:"testMode"`
-	StopOnEntry bool   `json:"stopOnEntry"`
-	NoDebug     bool   `json:"noDebug"`
+	Name        string   `json:"name"`
+	Program     string   `json:"program"`
+	WorkDir     string   `json:"workDir"`
+	Args        []string `json:"args"`
+	Input       string   `json:"input"`      // input mode: default, none, custom or failfast
+	Prompt      string   `json:"prompt"`     // custom input prompt
+	Transcript  bool     `json:"transcript"` // echo input to the output
+	TestMode    bool     `json:"testMode"`
+	StopOnEntry bool     `json:"stopOnEntry"`
+	NoDebug     bool     `json:"noDebug"`
 }
 
 func newEvent(event string) *api.Event {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/dragonsinth/gaddis/asm"
 	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
 	"sync/atomic"
 )
 
@@ -28,7 +29,10 @@ type ErrFrame struct {
 type Opts struct {
 	Input       <-chan string
 	Output      func(string)
+	Print       func(string)
 	WorkDir     string
+	Args        []string // program arguments
+	InputPolicy lib.InputPolicy
 	IsTest      bool
 	NoDebug     bool
 	StopOnEntry bool
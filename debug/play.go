@@ -48,12 +48,7 @@ func (ds *Session) play() {
 						p.Frame = nil // force a clean halt
 						return
 					}
-					var err error
-					if isErr, ok := r.(error); ok {
-						err = isErr
-					} else {
-						err = errors.New(fmt.Sprint(r))
-					}
+					err := p.NewRuntimeError(r)
 					p.AddPanicFrames()
 
 					log.Println("panicking:", err)
@@ -107,6 +102,7 @@ func (ds *Session) play() {
 		}
 
 		if p.Frame == nil {
+			exitCode = p.ExitCode
 			return
 		}
 
@@ -161,7 +157,12 @@ func (ds *Session) checkBreakpoints(p *asm.Execution) {
 }
 
 func (ds *Session) executePanic() {
-	ds.Opts.Output(fmt.Sprintf("error: %s\n", ds.exception.err))
+	var re *asm.RuntimeError
+	if errors.As(ds.exception.err, &re) {
+		ds.Opts.Output(re.Render(ds.Source.Path, ds.Source.Src))
+	} else {
+		ds.Opts.Output(fmt.Sprintf("error: %s\n", ds.exception.err))
+	}
 	ds.Opts.Output(ds.exception.trace)
 	ds.Host.Panicked(ds.exception.err, ds.exception.frames)
 }
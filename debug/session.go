@@ -3,6 +3,7 @@ package debug
 import (
 	"github.com/dragonsinth/gaddis"
 	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/lib"
 	"io"
 	"math/rand"
 	"sync/atomic"
@@ -48,21 +49,28 @@ func New(
 	opts Opts,
 ) *Session {
 	var seed int64
+	var clock lib.Clock = lib.SystemClock
 	if !opts.IsTest {
 		seed = time.Now().UnixNano()
+	} else {
+		clock = lib.NewFakeClock()
 	}
 
 	commands := make(chan func(bool))
 
 	var inputDelegate func() (string, error) // fill in below
 	exec := source.Assembled.NewExecution(&asm.ExecutionContext{
-		Rng: rand.New(rand.NewSource(seed)),
+		Rng:   rand.New(rand.NewSource(seed)),
+		Clock: clock,
+		Args:  opts.Args,
 		IoProvider: gaddis.IoAdapter{
 			In: func() (string, error) {
 				return inputDelegate()
 			},
 			Out:     opts.Output,
+			Prn:     opts.Print,
 			WorkDir: opts.WorkDir,
+			Policy:  opts.InputPolicy,
 		},
 	})
 
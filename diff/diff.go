@@ -0,0 +1,230 @@
+// Package diff produces readable line diffs of program output.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxTable bounds the work spent matching lines; beyond it, the differing middle of the
+// two texts is shown as replaced wholesale.
+const maxTable = 4 << 20
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind   opKind
+	line   string // includes the trailing newline, if any
+	wsOnly bool   // paired with a line that differs only in whitespace
+	aL, bL int    // 0-based line numbers in a and b before this op
+}
+
+// Unified returns a unified diff from a to b with the given lines of context,
+// or "" if they are equal.
+//
+// Lines which differ only in whitespace are shown with visible whitespace:
+// a space as '·' and a tab as '→'. A missing final newline is noted.
+func Unified(aName, bName string, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	markWhitespace(ops)
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	anyWs := false
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// extend the hunk until there are more than 2*context equal lines in a row
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != opEqual {
+				end = i + 1
+			} else if i-end >= 2*context {
+				break
+			}
+		}
+		lo, hi := max(0, start-context), min(len(ops), end+context)
+
+		var aN, bN int
+		for _, o := range ops[lo:hi] {
+			if o.kind != opInsert {
+				aN++
+			}
+			if o.kind != opDelete {
+				bN++
+			}
+		}
+		_, _ = fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(ops[lo].aL, aN), hunkRange(ops[lo].bL, bN))
+		for _, o := range ops[lo:hi] {
+			line := o.line
+			if o.wsOnly {
+				anyWs = true
+				line = showWhitespace(line)
+			}
+			sb.WriteByte(byte(o.kind))
+			if strings.HasSuffix(line, "\n") {
+				sb.WriteString(line)
+			} else {
+				sb.WriteString(line)
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = hi
+	}
+	if anyWs {
+		sb.WriteString("(lines shown with · and → differ only in whitespace: · is a space, → is a tab)\n")
+	}
+	return sb.String()
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines splits s into lines, keeping each line's newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b, using a longest common subsequence
+// of the lines between their common prefix and suffix.
+func diffLines(a, b []string) []op {
+	var ops []op
+	aL, bL := 0, 0
+	emit := func(kind opKind, line string) {
+		ops = append(ops, op{kind: kind, line: line, aL: aL, bL: bL})
+		if kind != opInsert {
+			aL++
+		}
+		if kind != opDelete {
+			bL++
+		}
+	}
+
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	for _, line := range a[:pre] {
+		emit(opEqual, line)
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma)*len(mb) > maxTable {
+		for _, line := range ma {
+			emit(opDelete, line)
+		}
+		for _, line := range mb {
+			emit(opInsert, line)
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:]
+		w := len(mb) + 1
+		lcs := make([]int, (len(ma)+1)*w)
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+				} else {
+					lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				emit(opEqual, ma[i])
+				i++
+				j++
+			case j == len(mb) || (i < len(ma) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+				emit(opDelete, ma[i])
+				i++
+			default:
+				emit(opInsert, mb[j])
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-suf:] {
+		emit(opEqual, line)
+	}
+	return ops
+}
+
+// markWhitespace pairs up each run of deleted lines with the inserted lines that follow it,
+// marking pairs that differ only in whitespace.
+func markWhitespace(ops []op) {
+	for i := 0; i < len(ops); {
+		if ops[i].kind != opDelete {
+			i++
+			continue
+		}
+		del := i
+		for i < len(ops) && ops[i].kind == opDelete {
+			i++
+		}
+		ins := i
+		for i < len(ops) && ops[i].kind == opInsert {
+			i++
+		}
+		for k := 0; k < ins-del && ins+k < i; k++ {
+			d, n := &ops[del+k], &ops[ins+k]
+			if strings.Join(strings.Fields(d.line), " ") == strings.Join(strings.Fields(n.line), " ") {
+				d.wsOnly, n.wsOnly = true, true
+			}
+		}
+	}
+}
+
+// showWhitespace makes spaces and tabs visible.
+func showWhitespace(line string) string {
+	body, nl := strings.CutSuffix(line, "\n")
+	body = strings.NewReplacer(" ", "·", "\t", "→").Replace(body)
+	if nl {
+		return body + "\n"
+	}
+	return body
+}
+
+// MatchedLines reports how many lines of want also appear in got, in order, out of the total in want.
+func MatchedLines(want, got string) (matched int, total int) {
+	for _, o := range diffLines(splitLines(want), splitLines(got)) {
+		if o.kind != opInsert {
+			total++
+		}
+		if o.kind == opEqual {
+			matched++
+		}
+	}
+	return matched, total
+}
@@ -0,0 +1,87 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line with context",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- want\n+++ got\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			b:    "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "--- want\n+++ got\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "insert and delete",
+			a:    "a\nb\nc\n",
+			b:    "a\nc\nd\n",
+			want: "--- want\n+++ got\n@@ -1,3 +1,3 @@\n a\n-b\n c\n+d\n",
+		},
+		{
+			name: "empty",
+			a:    "",
+			b:    "x\n",
+			want: "--- want\n+++ got\n@@ -0,0 +1 @@\n+x\n",
+		},
+		{
+			name: "trailing space",
+			a:    "total: 5\n",
+			b:    "total: 5 \n",
+			want: "--- want\n+++ got\n@@ -1 +1 @@\n-total:·5\n+total:·5·\n" +
+				"(lines shown with · and → differ only in whitespace: · is a space, → is a tab)\n",
+		},
+		{
+			name: "tab vs spaces",
+			a:    "a    b\n",
+			b:    "a\tb\n",
+			want: "--- want\n+++ got\n@@ -1 +1 @@\n-a····b\n+a→b\n" +
+				"(lines shown with · and → differ only in whitespace: · is a space, → is a tab)\n",
+		},
+		{
+			name: "missing final newline",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: "--- want\n+++ got\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n" +
+				"(lines shown with · and → differ only in whitespace: · is a space, → is a tab)\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Unified("want", "got", tc.a, tc.b, 3); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchedLines(t *testing.T) {
+	for _, tc := range []struct {
+		want, got      string
+		matched, total int
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", 3, 3},
+		{"a\nb\nc\n", "a\nx\nc\n", 2, 3},
+		{"a\nb\nc\n", "", 0, 3},
+		{"", "a\n", 0, 0},
+		{"a\nb\nc\nd\n", "b\nd\ne\n", 2, 4},
+	} {
+		matched, total := MatchedLines(tc.want, tc.got)
+		if matched != tc.matched || total != tc.total {
+			t.Errorf("MatchedLines(%q, %q) = %d, %d; want %d, %d", tc.want, tc.got, matched, total, tc.matched, tc.total)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package examples
+
+import (
+	"fmt"
+	"github.com/dragonsinth/gaddis/gogen/builtins"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestArithmeticTraps checks that constant folding, the interpreter, and the gogen runtime agree on
+// results, overflow, and division by zero.
+func TestArithmeticTraps(t *testing.T) {
+	for _, tc := range []struct {
+		typ  string
+		a, b string
+		op   string
+		want string // the result, or the error message
+	}{
+		{"Integer", "7", "2", "/", "3"},
+		{"Integer", "-7", "2", "MOD", "-1"},
+		{"Integer", "2", "62", "^", "4611686018427387904"},
+		{"Integer", "9223372036854775807", "1", "+", "integer overflow: 9223372036854775807 + 1"},
+		{"Integer", "-9223372036854775807", "2", "-", "integer overflow: -9223372036854775807 - 2"},
+		{"Integer", "4294967296", "4294967296", "*", "integer overflow: 4294967296 * 4294967296"},
+		{"Integer", "10", "19", "^", "integer overflow: 10 ^ 19"},
+		{"Integer", "5", "0", "/", "integer division by zero: 5 / 0"},
+		{"Integer", "5", "0", "MOD", "integer division by zero: 5 MOD 0"},
+		{"Real", "7.0", "2.0", "/", "3.5"},
+		{"Real", "1.5", "0.0", "/", "real division by zero: 1.5 / 0"},
+		{"Real", "1.5", "0.0", "MOD", "real division by zero: 1.5 MOD 0"},
+		{"Real", "10.0", "400.0", "^", "real overflow: 10 ^ 400"},
+		{"Real", "-8.0", "0.5", "^", "real result is not a number: -8 ^ 0.5"},
+	} {
+		name := fmt.Sprintf("%s %s %s", tc.a, tc.op, tc.b)
+
+		// constant folding reports a compile error
+		constSrc := fmt.Sprintf("Constant %s A = %s\nConstant %s B = %s\nDisplay A %s B\n", tc.typ, tc.a, tc.typ, tc.b, tc.op)
+		if got := runProgram(t, constSrc); got != tc.want {
+			t.Errorf("%s: constant: got %q, want %q", name, got, tc.want)
+		}
+
+		// the interpreter reports a runtime error
+		varSrc := strings.ReplaceAll(constSrc, "Constant", "Declare")
+		if got := runProgram(t, varSrc); got != tc.want {
+			t.Errorf("%s: interpreter: got %q, want %q", name, got, tc.want)
+		}
+
+		// the gogen runtime panics
+		if got := builtinArithmetic(tc.typ, tc.a, tc.b, tc.op); got != tc.want {
+			t.Errorf("%s: gogen: got %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestNegateOverflow(t *testing.T) {
+	const want = "integer overflow: -(-9223372036854775808)"
+	src := "Declare Integer a = -9223372036854775807 - 1\nDisplay -a\n"
+	if got := runProgram(t, src); got != want {
+		t.Errorf("interpreter: got %q, want %q", got, want)
+	}
+	if got := catch(func() any { return builtins.NegInteger(math.MinInt64) }); got != want {
+		t.Errorf("gogen: got %q, want %q", got, want)
+	}
+}
+
+// TestForLoopLimits checks that a For loop can count up to the largest Integer, or down to the
+// smallest, without overflowing on its final step.
+func TestForLoopLimits(t *testing.T) {
+	for _, tc := range []struct {
+		start, stop, step int64
+		want              string
+	}{
+		{math.MaxInt64 - 2, math.MaxInt64, 1, "9223372036854775805 9223372036854775806 9223372036854775807 / 9223372036854775807"},
+		{math.MinInt64 + 1, math.MinInt64, -1, "-9223372036854775807 -9223372036854775808 / -9223372036854775808"},
+		{1, 10, 4, "1 5 9 / 13"},
+		{3, 1, -1, "3 2 1 / 0"},
+		{5, 1, 1, "/ 5"},
+	} {
+		name := fmt.Sprintf("%d To %d Step %d", tc.start, tc.stop, tc.step)
+
+		src := fmt.Sprintf("Declare Integer i\nFor i = %d To %d Step %d\n    Display i\nEnd For\nDisplay \"/ \", i\n", tc.start, tc.stop, tc.step)
+		// the smallest Integer has no literal
+		src = strings.Replace(src, "-9223372036854775808", "-9223372036854775807 - 1", 1)
+		if got := strings.ReplaceAll(runProgram(t, src), "\n", " "); got != tc.want {
+			t.Errorf("%s: interpreter: got %q, want %q", name, got, tc.want)
+		}
+
+		// mirrors the loop gogen emits
+		var i int64
+		var sb strings.Builder
+		if tc.step < 0 && builtins.ForInteger(&i, tc.start) >= tc.stop || tc.step > 0 && builtins.ForInteger(&i, tc.start) <= tc.stop {
+			for {
+				_, _ = fmt.Fprintf(&sb, "%d ", i)
+				if !builtins.StepInteger(&i, tc.step, tc.stop) {
+					break
+				}
+			}
+		}
+		_, _ = fmt.Fprintf(&sb, "/ %d", i)
+		if got := sb.String(); got != tc.want {
+			t.Errorf("%s: gogen: got %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func builtinArithmetic(typ string, a, b string, op string) string {
+	if typ == "Integer" {
+		var x, y int64
+		_, _ = fmt.Sscan(a, &x)
+		_, _ = fmt.Sscan(b, &y)
+		fn := map[string]func(a, b int64) int64{
+			"+": builtins.AddInteger, "-": builtins.SubInteger, "*": builtins.MulInteger,
+			"/": builtins.DivInteger, "MOD": builtins.ModInteger, "^": builtins.ExpInteger,
+		}[op]
+		return catch(func() any { return fn(x, y) })
+	}
+	var x, y float64
+	_, _ = fmt.Sscan(a, &x)
+	_, _ = fmt.Sscan(b, &y)
+	fn := map[string]func(a, b float64) float64{
+		"+": builtins.AddReal, "-": builtins.SubReal, "*": builtins.MulReal,
+		"/": builtins.DivReal, "MOD": builtins.ModReal, "^": builtins.ExpReal,
+	}[op]
+	return catch(func() any { return fn(x, y) })
+}
+
+func catch(fn func() any) (ret string) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret = fmt.Sprint(r)
+		}
+	}()
+	return fmt.Sprint(fn())
+}
@@ -0,0 +1,39 @@
+package examples
+
+import "testing"
+
+// TestArraySizeChecks checks whole-array Set where the sizes are only known at runtime.
+func TestArraySizeChecks(t *testing.T) {
+	const funcs = `
+Function Integer[] two()
+	Declare Integer t[2] = 7, 8
+	Return t
+End Function
+
+Function Integer[] three()
+	Declare Integer t[3] = 7, 8, 9
+	Return t
+End Function
+
+Module setRef(Integer Ref arr[])
+	Set arr = two()
+End Module
+`
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"call", "Declare Integer a[3]\nSet a = three()\nDisplay a[2]\n", "9"},
+		{"call shrinks", "Declare Integer a[5]\nSet a = two()\n", "array size mismatch: [2] not assignable to [5]"},
+		{"call grows", "Declare Integer a[1]\nSet a = two()\n", "array size mismatch: [2] not assignable to [1]"},
+		{"row", "Declare Integer g[2][3]\nSet g[1] = three()\nDisplay g[1][2]\n", "9"},
+		{"row mismatch", "Declare Integer g[2][3]\nSet g[1] = two()\n", "array size mismatch: [2] not assignable to [3]"},
+		{"ref param", "Declare Integer a[2]\nCall setRef(a)\nDisplay a[1]\n", "8"},
+		{"ref param mismatch", "Declare Integer a[3]\nCall setRef(a)\n", "array size mismatch: [2] not assignable to [3]"},
+	} {
+		if got := runProgram(t, tc.src+funcs); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/dragonsinth/gaddis/ast"
 	"github.com/dragonsinth/gaddis/goexec"
 	"github.com/dragonsinth/gaddis/gogen"
+	"github.com/dragonsinth/gaddis/lib"
 	"io"
 	"math/rand"
 	"os"
@@ -63,8 +64,15 @@ func RunTestGo(t *testing.T, filename string) error {
 		t.Fatalf("failed to build %s: %v", filename, err)
 	}
 
+	var env []string
+	expectPrint, hasPrint := readPrintFile(filename)
+	printFile := filepath.Join(t.TempDir(), "print.txt")
+	if hasPrint {
+		env = append(env, lib.PrintEnv+"="+printFile)
+	}
+
 	workDir := filepath.Dir(filename)
-	err = goexec.Run(ctx, workDir, br.ExeFile, io.NopCloser(&input), &output, &errput)
+	err = goexec.Run(ctx, workDir, env, br.ExeFile, nil, io.NopCloser(&input), &output, &errput)
 	if err != nil {
 		t.Fatalf("failed to exec %s: %v", br.ExeFile, err)
 	}
@@ -76,6 +84,12 @@ func RunTestGo(t *testing.T, filename string) error {
 		// compare the output
 		t.Fatalf("wrong output, got=\n%s\nwant=%s", output.String(), string(expectOut))
 	}
+	if hasPrint {
+		gotPrint, _ := os.ReadFile(printFile)
+		if !bytes.Equal(gotPrint, expectPrint) {
+			t.Fatalf("wrong print output, got=\n%s\nwant=%s", string(gotPrint), string(expectPrint))
+		}
+	}
 
 	// Also check/update format on success
 	inSrc := string(src)
@@ -88,6 +102,56 @@ func RunTestGo(t *testing.T, filename string) error {
 }
 
 func RunTestInterp(t *testing.T, filename string) error {
+	return runTestInterp(t, filename, nil)
+}
+
+// RunTestBytecode is like RunTestInterp, but runs the program after a round trip through bytecode.
+func RunTestBytecode(t *testing.T, filename string) error {
+	return runTestInterp(t, filename, bytecodeRoundTrip)
+}
+
+// RunTestAsm is like RunTestInterp, but runs the program after a round trip through its dump.
+func RunTestAsm(t *testing.T, filename string) error {
+	return runTestInterp(t, filename, asmRoundTrip)
+}
+
+func bytecodeRoundTrip(t *testing.T, filename string, src string, cp *asm.Assembly) *asm.Assembly {
+	want := cp.Dump(src)
+	var buf bytes.Buffer
+	if err := cp.WriteBytecode(&buf, filename); err != nil {
+		t.Fatalf("failed to write bytecode for %s: %v", filename, err)
+	}
+	cp, gotFilename, err := asm.ReadBytecode(&buf)
+	if err != nil {
+		t.Fatalf("failed to read bytecode for %s: %v", filename, err)
+	}
+	if gotFilename != filename {
+		t.Fatalf("got filename %s, want %s", gotFilename, filename)
+	}
+	if dump := cp.Dump(src); dump != want {
+		t.Fatalf("bytecode changed the assembly, got=\n%s\nwant=%s", dump, want)
+	}
+	return cp
+}
+
+func asmRoundTrip(t *testing.T, filename string, src string, cp *asm.Assembly) *asm.Assembly {
+	want := cp.Dump(src)
+	got, err := asm.Assemble(want)
+	if err != nil {
+		t.Fatalf("failed to assemble %s: %v", filename, err)
+	}
+	for i, inst := range got.Code {
+		if wantInst := cp.Code[i]; fmt.Sprintf("%T %s", inst, inst) != fmt.Sprintf("%T %s", wantInst, wantInst) {
+			t.Fatalf("%s: got %T %s, want %T %s", asm.PcRef(i), inst, inst, wantInst, wantInst)
+		}
+	}
+	if dump := got.Dump(src); dump != want {
+		t.Fatalf("assembling changed the dump, got=\n%s\nwant=%s", dump, want)
+	}
+	return got
+}
+
+func runTestInterp(t *testing.T, filename string, roundTrip func(t *testing.T, filename string, src string, cp *asm.Assembly) *asm.Assembly) error {
 	srcBytes, err := os.ReadFile(filename)
 	if err != nil {
 		t.Fatalf("failed to read file %s: %v", filename, err)
@@ -103,6 +167,9 @@ func RunTestInterp(t *testing.T, filename string) error {
 	}
 
 	cp := asmgen.Assemble(prog)
+	if roundTrip != nil {
+		cp = roundTrip(t, filename, src, cp)
+	}
 
 	var input bytes.Buffer
 	var output bytes.Buffer
@@ -116,13 +183,21 @@ func RunTestInterp(t *testing.T, filename string) error {
 		t.Fatalf("failed to read file %s: %v", filename+".out", err)
 	}
 
+	var printput bytes.Buffer
+	expectPrint, hasPrint := readPrintFile(filename)
+	iop := gaddis.IoAdapter{
+		In:      gaddis.StreamInput(&input),
+		Out:     gaddis.StreamOutput(&output),
+		WorkDir: filepath.Dir(filename),
+	}
+	if hasPrint {
+		iop.Prn = gaddis.StreamOutput(&printput)
+	}
+
 	p := cp.NewExecution(&asm.ExecutionContext{
-		Rng: rand.New(rand.NewSource(0)),
-		IoProvider: gaddis.IoAdapter{
-			In:      gaddis.StreamInput(&input),
-			Out:     gaddis.StreamOutput(&output),
-			WorkDir: filepath.Dir(filename),
-		},
+		Rng:        rand.New(rand.NewSource(0)),
+		Clock:      lib.NewFakeClock(),
+		IoProvider: iop,
 	})
 
 	err = p.Run()
@@ -141,6 +216,9 @@ func RunTestInterp(t *testing.T, filename string) error {
 		// compare the output
 		t.Fatalf("wrong output, got=\n%s\nwant=%s", output.String(), string(expectOut))
 	}
+	if hasPrint && !bytes.Equal(printput.Bytes(), expectPrint) {
+		t.Fatalf("wrong print output, got=\n%s\nwant=%s", printput.String(), string(expectPrint))
+	}
 
 	// Also check/update format on success.
 	inSrc := string(src)
@@ -151,3 +229,9 @@ func RunTestInterp(t *testing.T, filename string) error {
 
 	return nil
 }
+
+// readPrintFile reads the expected Print output, if the test has any.
+func readPrintFile(filename string) ([]byte, bool) {
+	b, err := os.ReadFile(filename + ".print")
+	return b, err == nil
+}
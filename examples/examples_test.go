@@ -1,9 +1,14 @@
 package examples
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
 	"io/fs"
+	"math/rand"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -74,3 +79,86 @@ func TestExamplesInterp(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestExamplesBytecode(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	root := filepath.Dir(file)
+	if !strings.HasSuffix(root, slash) {
+		root += slash
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".gad") {
+			return nil
+		}
+		testname := strings.TrimPrefix(path, root)
+		testname = strings.ReplaceAll(testname, slash, "_")
+		t.Run(testname, func(t *testing.T) {
+			err := RunTestBytecode(t, path)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+		return err
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExamplesAsm(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	root := filepath.Dir(file)
+	if !strings.HasSuffix(root, slash) {
+		root += slash
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".gad") {
+			return nil
+		}
+		testname := strings.TrimPrefix(path, root)
+		testname = strings.ReplaceAll(testname, slash, "_")
+		t.Run(testname, func(t *testing.T) {
+			err := RunTestAsm(t, path)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+		return err
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// runProgram compiles and runs src, returning its output, compile error, or runtime error.
+func runProgram(t *testing.T, src string) string {
+	prog, _, errs := gaddis.Compile(src)
+	if len(errs) > 0 {
+		msg := errs[0].Desc
+		if len(errs) > 1 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+		return strings.TrimPrefix(msg, "type error: ")
+	}
+	var output bytes.Buffer
+	p := asmgen.Assemble(prog).NewExecution(&asm.ExecutionContext{
+		Rng:        rand.New(rand.NewSource(0)),
+		IoProvider: gaddis.IoAdapter{Out: gaddis.StreamOutput(&output)},
+	})
+	if err := p.Run(); err != nil {
+		return err.Error()
+	}
+	return strings.TrimSuffix(output.String(), "\n")
+}
@@ -2,4 +2,9 @@ module github.com/dragonsinth/gaddis
 
 go 1.22
 
-require github.com/google/go-dap v0.12.0
+require (
+	github.com/google/go-dap v0.12.0
+	golang.org/x/term v0.29.0
+)
+
+require golang.org/x/sys v0.30.0 // indirect
@@ -35,7 +35,8 @@ func Build(ctx context.Context, goSrc string, basename string) (BuildResult, err
 	return ret, nil
 }
 
-func Run(ctx context.Context, workDir string, execFile string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+// Run runs a compiled binary with the given arguments; env is added to the current environment.
+func Run(ctx context.Context, workDir string, env []string, execFile string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
 	// pipe so we can force a break
 	inPipe, stdinWriter := io.Pipe()
 	go func() {
@@ -44,14 +45,16 @@ func Run(ctx context.Context, workDir string, execFile string, stdin io.Reader,
 	}()
 
 	// Run the compiled binary
-	runCmd := exec.CommandContext(ctx, execFile)
+	runCmd := exec.CommandContext(ctx, execFile, args...)
 	runCmd.Dir = workDir
+	if len(env) > 0 {
+		runCmd.Env = append(os.Environ(), env...)
+	}
 	runCmd.Stdin = inPipe
 	stdoutPipe, _ := runCmd.StdoutPipe()
 	stderrPipe, _ := runCmd.StderrPipe()
 
 	var wgOut sync.WaitGroup
-	defer wgOut.Wait()
 	wgOut.Add(1)
 	go func() {
 		defer wgOut.Done()
@@ -71,5 +74,7 @@ func Run(ctx context.Context, workDir string, execFile string, stdin io.Reader,
 	if err := runCmd.Start(); err != nil {
 		return fmt.Errorf("could not start %s: %w", execFile, err)
 	}
+	// drain output before Wait closes the pipes
+	wgOut.Wait()
 	return runCmd.Wait()
 }
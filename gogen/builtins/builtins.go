@@ -1,8 +1,10 @@
 package builtins
 
 import (
+	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 )
 
 // Easier codegen.
@@ -10,10 +12,46 @@ import (
 type Integer = int64
 type Real = float64
 type String = string
-type Character = byte
+type Character = rune
 type Boolean = bool
 
+// Integer arithmetic traps on overflow and division by zero, and Real arithmetic traps on
+// results that are not finite; these must match ast.IntegerOp and ast.RealOp.
+
+func AddInteger(a, b Integer) Integer {
+	c := a + b
+	if (c > a) != (b > 0) {
+		integerOverflow("+", a, b)
+	}
+	return c
+}
+
+func SubInteger(a, b Integer) Integer {
+	c := a - b
+	if (c < a) != (b > 0) {
+		integerOverflow("-", a, b)
+	}
+	return c
+}
+
+func MulInteger(a, b Integer) Integer {
+	return mulChecked(a, b, "*", a, b)
+}
+
+func DivInteger(a, b Integer) Integer {
+	if b == 0 {
+		panic(fmt.Sprintf("integer division by zero: %d / %d", a, b))
+	}
+	if a == math.MinInt64 && b == -1 {
+		integerOverflow("/", a, b)
+	}
+	return a / b
+}
+
 func ModInteger(a, b Integer) Integer {
+	if b == 0 {
+		panic(fmt.Sprintf("integer division by zero: %d MOD %d", a, b))
+	}
 	return a % b
 }
 
@@ -26,25 +64,86 @@ func ExpInteger(base, exp Integer) Integer {
 	}
 
 	result := Integer(1)
-	for {
-		if exp&1 == 1 { // Check if the least significant bit of exp is 1
-			result *= base
+	for b, e := base, exp; ; {
+		if e&1 == 1 { // Check if the least significant bit of exp is 1
+			result = mulChecked(result, b, "^", base, exp)
 		}
-		exp >>= 1 // Right shift exp (equivalent to dividing by 2)
-		if exp == 0 {
+		e >>= 1 // Right shift exp (equivalent to dividing by 2)
+		if e == 0 {
 			break
 		}
-		base *= base // Square the base
+		b = mulChecked(b, b, "^", base, exp) // Square the base
 	}
 	return result
 }
 
+func NegInteger(a Integer) Integer {
+	if a == math.MinInt64 {
+		panic(fmt.Sprintf("integer overflow: -(%d)", a))
+	}
+	return -a
+}
+
+func mulChecked(a, b Integer, op string, x, y Integer) Integer {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		integerOverflow(op, x, y)
+	}
+	return c
+}
+
+func integerOverflow(op string, a, b Integer) {
+	panic(fmt.Sprintf("integer overflow: %d %s %d", a, op, b))
+}
+
+func AddReal(a, b Real) Real {
+	return checkReal("+", a, b, a+b)
+}
+
+func SubReal(a, b Real) Real {
+	return checkReal("-", a, b, a-b)
+}
+
+func MulReal(a, b Real) Real {
+	return checkReal("*", a, b, a*b)
+}
+
+func DivReal(a, b Real) Real {
+	if b == 0 {
+		panic("real division by zero: " + formatRealOp("/", a, b))
+	}
+	return checkReal("/", a, b, a/b)
+}
+
 func ModReal(a, b Real) Real {
-	return math.Mod(a, b)
+	if b == 0 {
+		panic("real division by zero: " + formatRealOp("MOD", a, b))
+	}
+	return checkReal("MOD", a, b, math.Mod(a, b))
 }
 
 func ExpReal(base, exp Real) Real {
-	return math.Pow(base, exp)
+	return checkReal("^", base, exp, math.Pow(base, exp))
+}
+
+func checkReal(op string, a, b, ret Real) Real {
+	if !math.IsNaN(ret) && !math.IsInf(ret, 0) {
+		return ret
+	}
+	if math.IsNaN(a) || math.IsInf(a, 0) || math.IsNaN(b) || math.IsInf(b, 0) {
+		return ret // garbage in, garbage out
+	}
+	if math.IsInf(ret, 0) {
+		panic("real overflow: " + formatRealOp(op, a, b))
+	}
+	panic("real result is not a number: " + formatRealOp(op, a, b))
+}
+
+func formatRealOp(op string, a, b Real) string {
+	return strconv.FormatFloat(a, 'g', -1, 64) + " " + op + " " + strconv.FormatFloat(b, 'g', -1, 64)
 }
 
 func ForInteger(ref *Integer, start Integer) Integer {
@@ -52,9 +151,21 @@ func ForInteger(ref *Integer, start Integer) Integer {
 	return *ref
 }
 
-func StepInteger(ref *Integer, step Integer) Integer {
+// StepInteger advances a For loop counter, reporting whether the loop should continue. If the next
+// step would overflow, the counter can't pass stop, so the loop ends with the counter unchanged.
+func StepInteger(ref *Integer, step Integer, stop Integer) bool {
+	if step < 0 {
+		if *ref < math.MinInt64-step {
+			return false
+		}
+		*ref += step
+		return *ref >= stop
+	}
+	if *ref > math.MaxInt64-step {
+		return false
+	}
 	*ref += step
-	return *ref
+	return *ref <= stop
 }
 
 func ForReal(ref *Real, start Real) Real {
@@ -63,7 +174,7 @@ func ForReal(ref *Real, start Real) Real {
 }
 
 func StepReal(ref *Real, step Real) Real {
-	*ref += step
+	*ref = AddReal(*ref, step)
 	return *ref
 }
 
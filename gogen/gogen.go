@@ -18,6 +18,8 @@ func GoGenerate(prog *ast.Program, isTest bool) string {
 	var imports, code []string
 	parseGoCode(lib.IoSource, &imports, &code)
 	parseGoCode(lib.LibSource, &imports, &code)
+	parseGoCode(lib.TimeSource, &imports, &code)
+	parseGoCode(lib.ErrorsSource, &imports, &code)
 	parseGoCode(builtins, &imports, &code)
 
 	slices.Sort(imports)
@@ -37,8 +39,11 @@ func GoGenerate(prog *ast.Program, isTest bool) string {
 	v := New("", &sb)
 
 	if isTest {
-		// Lock the rng in test mode.
-		v.output("func init() { randCtx.Rng.Seed(0) }\n\n")
+		// Lock the rng and clock in test mode.
+		v.output("func init() {\n")
+		v.output("\trandCtx.Rng.Seed(0)\n")
+		v.output("\t*timeCtx = *NewTimeContext(NewFakeClock())\n")
+		v.output("}\n\n")
 	}
 
 	// First, iterate the global block and emit any declarations.
@@ -67,6 +72,8 @@ func GoGenerate(prog *ast.Program, isTest bool) string {
 	// Now, emit any non-declarations into the main function.
 	sb.WriteString("\nfunc main() {\n")
 	v.PreVisitBlock(prog.Block)
+	v.indent()
+	v.output("defer closePrinter()\n")
 	for _, stmt := range prog.Block.Statements {
 		switch stmt := stmt.(type) {
 		case *ast.DeclareStmt:
@@ -182,7 +189,11 @@ func (v *Visitor) PostVisitDeclareStmt(stmt *ast.DeclareStmt) {
 
 func (v *Visitor) PreVisitDisplayStmt(d *ast.DisplayStmt) bool {
 	v.indent()
-	v.output("Display(")
+	if d.IsPrint {
+		v.output("Print(")
+	} else {
+		v.output("Display(")
+	}
 	for i, arg := range d.Exprs {
 		if i > 0 {
 			v.output(", ")
@@ -216,6 +227,15 @@ func (v *Visitor) PostVisitInputStmt(i *ast.InputStmt) {
 
 func (v *Visitor) PreVisitSetStmt(s *ast.SetStmt) bool {
 	v.indent()
+	if s.Ref.GetType().IsArrayType() && (ast.StaticDims(s.Ref) == nil || ast.StaticDims(s.Expr) == nil) {
+		// typecheck couldn't compare the sizes, check them at runtime
+		v.output("assignArray(")
+		v.varRef(s.Ref, true)
+		v.output(", ")
+		v.maybeCast(s.Ref.GetType(), s.Expr)
+		v.output(")\n")
+		return false
+	}
 	v.emitAssignment(s.Ref, func() {
 		v.maybeCast(s.Ref.GetType(), s.Expr)
 	})
@@ -228,11 +248,11 @@ func (v *Visitor) emitAssignment(lhs ast.Expression, emitRhs func()) {
 		ar := lhs.(*ast.ArrayRef)
 		// special case string index assignment
 		if ar.Qualifier.CanReference() {
-			// stringWithCharUpdateRef(str *string, idx int64, c byte)
+			// stringWithCharUpdateRef(str *string, idx int64, c rune)
 			v.output("stringWithCharUpdateRef(")
 			v.varRef(ar.Qualifier, true)
 		} else {
-			// eval and ignore: stringWithCharUpdate(str string, idx int64, c byte)
+			// eval and ignore: stringWithCharUpdate(str string, idx int64, c rune)
 			v.output("stringWithCharUpdate(")
 			ar.Qualifier.Visit(v)
 		}
@@ -264,6 +284,12 @@ func (v *Visitor) PreVisitOpenStmt(os *ast.OpenStmt) bool {
 	os.File.Visit(v)
 	v.output(", ")
 	os.Name.Visit(v)
+	v.output(", ")
+	if os.RecordSize != nil {
+		os.RecordSize.Visit(v)
+	} else {
+		v.output(strconv.Itoa(int(os.Format)))
+	}
 	v.output(")\n")
 	return false
 }
@@ -272,9 +298,12 @@ func (v *Visitor) PostVisitOpenStmt(os *ast.OpenStmt) {}
 
 func (v *Visitor) PreVisitCloseStmt(cs *ast.CloseStmt) bool {
 	v.indent()
-	if cs.File.GetType() == ast.InputFile {
+	switch cs.File.GetType() {
+	case ast.InputFile:
 		v.output("CloseInputFile(")
-	} else {
+	case ast.RandomFile:
+		v.output("CloseRandomFile(")
+	default:
 		v.output("CloseOutputFile(")
 	}
 	cs.File.Visit(v)
@@ -289,12 +318,19 @@ func (v *Visitor) PreVisitReadStmt(rs *ast.ReadStmt) bool {
 		v.indent()
 		v.varRef(expr, false)
 		v.output(" = Read")
+		if rs.File.GetType() == ast.RandomFile {
+			v.output("Random")
+		}
 		v.typeName(expr.GetType().AsPrimitive())
 		v.output("(")
 		rs.File.Visit(v)
 		v.output(", ")
-		// non-final field sep -> final record sep
-		v.output(strconv.FormatBool(i < len(rs.Exprs)-1))
+		// field index, and field count of the record except for random access records
+		v.output(strconv.Itoa(i))
+		if rs.File.GetType() != ast.RandomFile {
+			v.output(", ")
+			v.output(strconv.Itoa(len(rs.Exprs)))
+		}
 		v.output(")\n")
 	}
 	return false
@@ -304,7 +340,11 @@ func (v *Visitor) PostVisitReadStmt(rs *ast.ReadStmt) {}
 
 func (v *Visitor) PreVisitWriteStmt(ws *ast.WriteStmt) bool {
 	v.indent()
-	v.output("WriteFile(")
+	if ws.File.GetType() == ast.RandomFile {
+		v.output("WriteRandomFile(")
+	} else {
+		v.output("WriteFile(")
+	}
 	ws.File.Visit(v)
 	for _, expr := range ws.Exprs {
 		v.output(", ")
@@ -461,7 +501,7 @@ func (v *Visitor) PreVisitForStmt(fs *ast.ForStmt) bool {
 		if ForInteger(&count_, 1) <= 10 {
 			for {
 				Display(count_)
-				if !StepInteger(&count_, 1) <= 10 {
+				if !StepInteger(&count_, 1, 10) {
 					break
 				}
 			}
@@ -505,7 +545,11 @@ func (v *Visitor) PreVisitForStmt(fs *ast.ForStmt) bool {
 	v.ind += "\t"
 
 	v.indent()
-	v.output("if Step")
+	if refType == ast.Integer {
+		v.output("if !Step")
+	} else {
+		v.output("if Step")
+	}
 	v.output(refType.String())
 	v.output("(")
 	v.varRef(fs.Ref, true)
@@ -515,12 +559,18 @@ func (v *Visitor) PreVisitForStmt(fs *ast.ForStmt) bool {
 	} else {
 		v.output("1")
 	}
-	if isNegative {
+	if refType == ast.Integer {
+		// StepInteger compares against stop itself, so it can end the loop rather than overflow
+		v.output(", ")
+	} else if isNegative {
 		v.output(") < ")
 	} else {
 		v.output(") > ")
 	}
 	v.maybeCast(refType, fs.StopExpr)
+	if refType == ast.Integer {
+		v.output(")")
+	}
 	v.output(" {\n")
 	v.indent()
 	v.output("\tbreak\n")
@@ -867,7 +917,7 @@ func (v *Visitor) PreVisitLiteral(l *ast.Literal) bool {
 	case ast.String:
 		v.output(strconv.Quote(l.Val.(string)))
 	case ast.Character:
-		v.output(strconv.QuoteRune(rune(l.Val.(byte))))
+		v.output(strconv.QuoteRune(l.Val.(rune)))
 	case ast.Boolean:
 		v.output(strconv.FormatBool(l.Val.(bool)))
 	default:
@@ -890,7 +940,11 @@ func (v *Visitor) PreVisitUnaryOperation(uo *ast.UnaryOperation) bool {
 	case ast.NOT:
 		v.output("!")
 	case ast.NEG:
-		v.output("-")
+		if uo.Type == ast.Integer {
+			v.output("NegInteger")
+		} else {
+			v.output("-")
+		}
 	default:
 		panic(uo.Op)
 	}
@@ -906,13 +960,9 @@ func (v *Visitor) PostVisitUnaryOperation(uo *ast.UnaryOperation) {
 func (v *Visitor) PreVisitBinaryOperation(bo *ast.BinaryOperation) bool {
 	argType := bo.ArgType
 
-	// must special case exp and mod
-	if bo.Op == ast.MOD || bo.Op == ast.EXP {
-		if bo.Op == ast.MOD {
-			v.output("Mod")
-		} else {
-			v.output("Exp")
-		}
+	// arithmetic calls runtime helpers which trap on overflow and division by zero
+	if fn := goArithmeticFuncs[bo.Op]; fn != "" {
+		v.output(fn)
 		v.output(bo.Type.String())
 		v.output("(")
 		v.maybeCast(argType, bo.Lhs)
@@ -958,7 +1008,21 @@ func (v *Visitor) PreVisitCallExpr(ce *ast.CallExpr) bool {
 		v.ident(ce.Ref)
 	}
 	v.output("(")
-	v.outputArgumentList(ce.Args, ce.Ref.Params)
+	if n := len(ce.Ref.Params); ce.Ref.IsVariadic {
+		v.outputArgumentList(ce.Args[:n], ce.Ref.Params)
+		for i, arg := range ce.Args[n:] {
+			// explicitly type untyped constants
+			if n+i > 0 {
+				v.output(", ")
+			}
+			v.typeName(arg.GetType())
+			v.output("(")
+			arg.Visit(v)
+			v.output(")")
+		}
+	} else {
+		v.outputArgumentList(ce.Args, ce.Ref.Params)
+	}
 	v.output(")")
 	return false
 }
@@ -966,6 +1030,15 @@ func (v *Visitor) PreVisitCallExpr(ce *ast.CallExpr) bool {
 func (v *Visitor) PostVisitCallExpr(ce *ast.CallExpr) {}
 
 func (v *Visitor) PreVisitArrayRef(ar *ast.ArrayRef) bool {
+	if ar.Qualifier.GetType() == ast.String {
+		// index by character rather than by byte
+		v.output("CharAt(")
+		ar.Qualifier.Visit(v)
+		v.output(", ")
+		ar.IndexExpr.Visit(v)
+		v.output(")")
+		return false
+	}
 	// TODO: this won't be sufficient for passing in as a reference param
 	ar.Qualifier.Visit(v)
 	v.output("[")
@@ -1063,7 +1136,7 @@ func (v *Visitor) outputLiteral(typ ast.PrimitiveType, val any) {
 	case ast.String:
 		v.output(strconv.Quote(val.(string)))
 	case ast.Character:
-		v.output(strconv.QuoteRune(rune(val.(byte))))
+		v.output(strconv.QuoteRune(val.(rune)))
 	case ast.Boolean:
 		v.output(strconv.FormatBool(val.(bool)))
 	default:
@@ -1084,6 +1157,11 @@ func (v *Visitor) maybeCast(dstType ast.Type, exp ast.Expression) {
 		v.output("String(")
 		exp.Visit(v)
 		v.output(")")
+	} else if dstType.IsArrayType() && !ast.IsFreshArray(exp) {
+		// arrays are copied on assignment and return, just like pass by value
+		v.output("Clone(")
+		exp.Visit(v)
+		v.output(")")
 	} else if ast.IsSubclass(dstType, exp.GetType()) {
 		v.output("(&")
 		exp.Visit(v)
@@ -1216,16 +1294,23 @@ func (v *Visitor) zero(typ ast.Type) {
 		v.output("AppendFile{}")
 	case ast.InputFile:
 		v.output("InputFile{}")
+	case ast.RandomFile:
+		v.output("RandomFile{}")
 	default:
 		v.output("nil")
 	}
 }
 
+var goArithmeticFuncs = map[ast.Operator]string{
+	ast.ADD: "Add",
+	ast.SUB: "Sub",
+	ast.MUL: "Mul",
+	ast.DIV: "Div",
+	ast.EXP: "Exp",
+	ast.MOD: "Mod",
+}
+
 var goBinaryOperators = [...]string{
-	ast.ADD: "+",
-	ast.SUB: "-",
-	ast.MUL: "*",
-	ast.DIV: "/",
 	ast.EQ:  "==",
 	ast.NEQ: "!=",
 	ast.LT:  "<",
@@ -0,0 +1,145 @@
+package grade
+
+import (
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/base"
+	"slices"
+	"strings"
+)
+
+// constructNames are the constructs a rubric can require, besides "Function <Type>".
+var constructNames = []string{
+	"If", "Select", "While", "Do While", "Do Until", "For", "For Each",
+	"Module", "Function", "Class", "Array", "Input", "Display", "Call", "File",
+}
+
+// IsConstruct reports whether name is a construct that [Constructs] can find.
+func IsConstruct(name string) bool {
+	name = normalizeConstruct(name)
+	if typ, ok := strings.CutPrefix(name, "function "); ok && typ != "" {
+		return true
+	}
+	return slices.ContainsFunc(constructNames, func(s string) bool {
+		return normalizeConstruct(s) == name
+	})
+}
+
+// HasConstruct reports whether the program uses the named construct.
+func HasConstruct(prog *ast.Program, name string) bool {
+	return Constructs(prog)[normalizeConstruct(name)]
+}
+
+// Constructs returns the set of constructs the program uses, in lowercase.
+// "Module" means a Module other than main, "Function <Type>" is a Function returning that type,
+// "Array" is any array variable or parameter, and "File" is any file statement.
+func Constructs(prog *ast.Program) map[string]bool {
+	v := &constructVisitor{found: map[string]bool{}}
+	prog.Visit(v)
+	return v.found
+}
+
+func normalizeConstruct(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+type constructVisitor struct {
+	base.Visitor
+	found map[string]bool
+}
+
+var _ ast.Visitor = &constructVisitor{}
+
+func (v *constructVisitor) add(name string) {
+	v.found[normalizeConstruct(name)] = true
+}
+
+func (v *constructVisitor) PreVisitVarDecl(vd *ast.VarDecl) bool {
+	if vd.Type != nil && vd.Type.IsArrayType() {
+		v.add("Array")
+	}
+	return true
+}
+
+func (v *constructVisitor) PreVisitDisplayStmt(ds *ast.DisplayStmt) bool {
+	v.add("Display")
+	return true
+}
+
+func (v *constructVisitor) PreVisitInputStmt(is *ast.InputStmt) bool {
+	v.add("Input")
+	return true
+}
+
+func (v *constructVisitor) PreVisitOpenStmt(os *ast.OpenStmt) bool {
+	v.add("File")
+	return true
+}
+
+func (v *constructVisitor) PreVisitReadStmt(rs *ast.ReadStmt) bool {
+	v.add("File")
+	return true
+}
+
+func (v *constructVisitor) PreVisitWriteStmt(ws *ast.WriteStmt) bool {
+	v.add("File")
+	return true
+}
+
+func (v *constructVisitor) PreVisitIfStmt(is *ast.IfStmt) bool {
+	v.add("If")
+	return true
+}
+
+func (v *constructVisitor) PreVisitSelectStmt(ss *ast.SelectStmt) bool {
+	v.add("Select")
+	return true
+}
+
+func (v *constructVisitor) PreVisitDoStmt(ds *ast.DoStmt) bool {
+	if ds.Until {
+		v.add("Do Until")
+	} else {
+		v.add("Do While")
+	}
+	return true
+}
+
+func (v *constructVisitor) PreVisitWhileStmt(ws *ast.WhileStmt) bool {
+	v.add("While")
+	return true
+}
+
+func (v *constructVisitor) PreVisitForStmt(fs *ast.ForStmt) bool {
+	v.add("For")
+	return true
+}
+
+func (v *constructVisitor) PreVisitForEachStmt(fs *ast.ForEachStmt) bool {
+	v.add("For Each")
+	return true
+}
+
+func (v *constructVisitor) PreVisitCallStmt(cs *ast.CallStmt) bool {
+	v.add("Call")
+	return true
+}
+
+func (v *constructVisitor) PreVisitModuleStmt(ms *ast.ModuleStmt) bool {
+	if ms.Name != "main" {
+		v.add("Module")
+	}
+	return true
+}
+
+func (v *constructVisitor) PreVisitFunctionStmt(fs *ast.FunctionStmt) bool {
+	v.add("Function")
+	if fs.Type != nil {
+		v.add("Function " + fs.Type.String())
+	}
+	return true
+}
+
+func (v *constructVisitor) PreVisitClassStmt(cs *ast.ClassStmt) bool {
+	v.add("Class")
+	return true
+}
@@ -0,0 +1,253 @@
+package grade
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/diff"
+	"github.com/dragonsinth/gaddis/lib"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Limits on each test run, unless the rubric sets its own; a runaway program shouldn't hold up
+// or exhaust the grader.
+const (
+	DefaultMaxOutput = 1 << 20
+	DefaultTimeout   = 10 * time.Second
+)
+
+// Item kinds.
+const (
+	TestItem      = "test"
+	ConstructItem = "construct"
+	LintItem      = "lint"
+)
+
+// Item is the score for one rubric item.
+type Item struct {
+	Kind   string  `json:"kind"`
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+	Max    float64 `json:"max"`
+	Hidden bool    `json:"hidden,omitempty"`
+	Detail string  `json:"detail,omitempty"` // why points were lost
+}
+
+// Report is one submission's score.
+type Report struct {
+	Student string  `json:"student"`
+	Score   float64 `json:"score"`
+	Max     float64 `json:"max"`
+	Error   string  `json:"error,omitempty"` // why the program couldn't run
+	Items   []Item  `json:"items"`
+}
+
+// ForStudent returns a copy of the report that doesn't reveal the names or output of hidden tests.
+func (r *Report) ForStudent() *Report {
+	ret := *r
+	ret.Items = make([]Item, len(r.Items))
+	hidden := 0
+	for i, it := range r.Items {
+		if it.Hidden {
+			hidden++
+			it.Name = fmt.Sprintf("hidden test %d", hidden)
+			if it.Detail != "" {
+				it.Detail = "wrong output"
+			}
+		}
+		ret.Items[i] = it
+	}
+	return &ret
+}
+
+// Grade scores the submission in dir. Submissions are never modified: each test runs in a
+// temporary copy of dir, so files the program writes are thrown away.
+func (r *Rubric) Grade(dir string) *Report {
+	rep := &Report{
+		Student: filepath.Base(dir),
+		Max:     r.MaxPoints(),
+	}
+	for _, t := range r.Tests {
+		rep.Items = append(rep.Items, Item{Kind: TestItem, Name: t.Name, Max: t.Points, Hidden: t.Hidden})
+	}
+	for _, c := range r.Constructs {
+		rep.Items = append(rep.Items, Item{Kind: ConstructItem, Name: c.Name, Max: c.Points})
+	}
+	if r.Lint != nil {
+		rep.Items = append(rep.Items, Item{Kind: LintItem, Name: r.Lint.Name, Max: r.Lint.Points})
+	}
+	tests := rep.Items[:len(r.Tests)]
+	constructs := rep.Items[len(r.Tests) : len(r.Tests)+len(r.Constructs)]
+
+	filename := filepath.Join(dir, r.Program)
+	srcBytes, err := os.ReadFile(filename)
+	if err != nil {
+		rep.Error = err.Error()
+		return rep
+	}
+	src := string(srcBytes)
+
+	prog, outSrc, errs := gaddis.Compile(src)
+	if len(errs) > 0 {
+		var sb strings.Builder
+		for _, err := range ast.ErrorSort(errs) {
+			_, _ = fmt.Fprintf(&sb, "%s:%v\n", r.Program, err)
+		}
+		rep.Error = sb.String()
+	}
+
+	// constructs can be found as long as the program parses
+	if outSrc != "" {
+		found := Constructs(prog)
+		for i, c := range r.Constructs {
+			if found[normalizeConstruct(c.Require)] {
+				constructs[i].Points = c.Points
+			} else {
+				constructs[i].Detail = "not found: " + c.Require
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		assembled := asmgen.Assemble(prog)
+		for i, t := range r.Tests {
+			tests[i].Points, tests[i].Detail = r.runTest(t, assembled, dir, src)
+		}
+		if r.Lint != nil {
+			lint := &rep.Items[len(rep.Items)-1]
+			if src == outSrc {
+				lint.Points = r.Lint.Points
+			} else {
+				lint.Detail = "not formatted; run gaddis format"
+			}
+		}
+	}
+
+	for _, it := range rep.Items {
+		rep.Score += it.Points
+	}
+	rep.Score = round(rep.Score)
+	return rep
+}
+
+// runTest runs one test, returning the points earned and why any were lost.
+func (r *Rubric) runTest(t Test, assembled *asm.Assembly, dir string, src string) (float64, string) {
+	var input []byte
+	if t.Input != "" {
+		var err error
+		if input, err = os.ReadFile(r.path(t.Input)); err != nil {
+			return 0, err.Error()
+		}
+	}
+	want, err := os.ReadFile(r.path(t.Output))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	workDir, err := os.MkdirTemp("", "gaddis-grade-*")
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+	if err := copyDir(workDir, dir); err != nil {
+		return 0, err.Error()
+	}
+
+	maxOutput := DefaultMaxOutput
+	if r.MaxOutput > 0 {
+		maxOutput = r.MaxOutput
+	}
+	timeout := DefaultTimeout
+	if r.Timeout > 0 {
+		timeout = time.Duration(r.Timeout * float64(time.Second))
+	}
+	var expired atomic.Bool
+	timer := time.AfterFunc(timeout, func() { expired.Store(true) })
+	defer timer.Stop()
+
+	var output bytes.Buffer
+	p := assembled.NewExecution(&asm.ExecutionContext{
+		Rng:   rand.New(rand.NewSource(0)),
+		Clock: lib.NewFakeClock(),
+		IoProvider: gaddis.IoAdapter{
+			In: gaddis.StreamInput(bytes.NewReader(input)),
+			Out: func(s string) {
+				if output.Len()+len(s) > maxOutput {
+					panic(fmt.Sprintf("output limit exceeded: more than %d bytes", maxOutput))
+				}
+				output.WriteString(s)
+			},
+			WorkDir: workDir,
+		},
+		MaxInstructions: r.MaxInstructions,
+		Hook: func(p *asm.Execution, inst asm.Inst) {
+			if expired.Load() {
+				panic(fmt.Sprintf("time limit exceeded: ran longer than %v", timeout))
+			}
+		},
+	})
+	var detail string
+	if err := p.Run(); err != nil {
+		var re *asm.RuntimeError
+		if errors.As(err, &re) {
+			detail = re.Render(r.Program, src)
+		} else {
+			detail = err.Error() + "\n"
+		}
+	}
+
+	if bytes.Equal(output.Bytes(), want) && detail == "" {
+		return t.Points, ""
+	}
+	detail += diff.Unified(t.Name+".out", "got", string(want), output.String(), 3)
+	if !t.Partial {
+		return 0, detail
+	}
+	matched, total := diff.MatchedLines(string(want), output.String())
+	if total == 0 {
+		return 0, detail
+	}
+	return round(t.Points * float64(matched) / float64(total)), detail
+}
+
+// copyDir copies the regular files and directories under src into dst; symlinks are skipped so
+// the copy can't reach outside itself.
+func copyDir(dst string, src string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, 0644)
+		default:
+			return nil
+		}
+	})
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,188 @@
+package grade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const rubricJson = `{
+  "program": "double.gad",
+  "tests": [
+    {"name": "small", "points": 4, "input": "small.in", "output": "small.out"},
+    {"points": 4, "input": "big.in", "output": "big.out", "partial": true, "hidden": true}
+  ],
+  "constructs": [
+    {"name": "uses a For loop", "points": 1, "require": "For"},
+    {"points": 2, "require": "Function Integer"}
+  ],
+  "lint": {"points": 1}
+}`
+
+const goodSrc = `Function Integer double(Integer n)
+	Return n * 2
+End Function
+
+Declare Integer count, i, n
+Input count
+For i = 1 To count
+	Input n
+	Display double(n)
+End For
+`
+
+// prints the wrong value for negative numbers, isn't formatted, and uses While instead of For
+const partialSrc = `Declare Integer count, i = 0, n
+Input count
+While i < count
+  Input n
+  If n < 0 Then
+    Display n
+  Else
+    Display n * 2
+  End If
+  Set i = i + 1
+End While
+`
+
+func TestGrade(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"rubric.json":      rubricJson,
+		"small.in":         "1\n3\n",
+		"small.out":        "integer> integer> 6\n",
+		"big.in":           "4\n1\n-2\n3\n-4\n",
+		"big.out":          "integer> integer> 2\ninteger> -4\ninteger> 6\ninteger> -8\n",
+		"alice/double.gad": goodSrc,
+		"bob/double.gad":   partialSrc,
+		"carol/double.gad": "Display x\n",
+		"dave/readme.txt":  "",
+	})
+
+	r, err := LoadRubric(filepath.Join(dir, "rubric.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MaxPoints() != 12 {
+		t.Errorf("max points: got %v, want 12", r.MaxPoints())
+	}
+
+	for _, tc := range []struct {
+		student string
+		score   float64
+		points  []float64
+	}{
+		{"alice", 12, []float64{4, 4, 1, 2, 1}},
+		{"bob", 6, []float64{4, 2, 0, 0, 0}},
+		{"carol", 0, []float64{0, 0, 0, 0, 0}},
+		{"dave", 0, []float64{0, 0, 0, 0, 0}},
+	} {
+		rep := r.Grade(filepath.Join(dir, tc.student))
+		if rep.Score != tc.score {
+			t.Errorf("%s: got score %v, want %v: %+v", tc.student, rep.Score, tc.score, rep)
+		}
+		for i, it := range rep.Items {
+			if it.Points != tc.points[i] {
+				t.Errorf("%s: %s: got %v points, want %v: %s", tc.student, it.Name, it.Points, tc.points[i], it.Detail)
+			}
+		}
+	}
+
+	rep := r.Grade(filepath.Join(dir, "bob")).ForStudent()
+	if it := rep.Items[1]; it.Name != "hidden test 1" || it.Detail != "wrong output" {
+		t.Errorf("hidden test revealed: %+v", it)
+	}
+}
+
+// a program that reads a data file from its submission, then writes a file of its own
+const fileSrc = `Declare InputFile data
+Declare OutputFile junk
+Declare String line
+Open data "data.txt"
+Read data line
+Close data
+Open junk "junk.txt"
+Write junk line
+Close junk
+Display line
+`
+
+func TestGradeSandbox(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"rubric.json":    `{"program": "files.gad", "tests": [{"name": "a", "points": 1, "output": "a.out"}, {"name": "b", "points": 1, "output": "a.out"}]}`,
+		"a.out":          "hello\n",
+		"erin/files.gad": fileSrc,
+		"erin/data.txt":  `"hello"` + "\n",
+	})
+	r, err := LoadRubric(filepath.Join(dir, "rubric.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both tests pass, so neither sees the other's junk.txt
+	if rep := r.Grade(filepath.Join(dir, "erin")); rep.Score != 2 {
+		t.Errorf("got score %v, want 2: %+v", rep.Score, rep)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "erin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("submission was modified: %v", entries)
+	}
+}
+
+func TestGradeLimits(t *testing.T) {
+	const loopSrc = "Declare Integer n = 0\nWhile n >= 0\n\tDisplay \"%s\"\n\tSet n = n + 1\nEnd While\n"
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"output.json":      `{"program": "loop.gad", "maxOutput": 100, "tests": [{"points": 1, "output": "a.out"}]}`,
+		"timeout.json":     `{"program": "loop.gad", "timeout": 0.05, "tests": [{"points": 1, "output": "a.out"}]}`,
+		"a.out":            "done\n",
+		"chatty/loop.gad":  fmt.Sprintf(loopSrc, "x"),
+		"endless/loop.gad": strings.Replace(fmt.Sprintf(loopSrc, ""), "\tDisplay \"\"\n", "", 1),
+	})
+	for _, tc := range []struct {
+		rubric, student string
+		want            string
+	}{
+		{"output.json", "chatty", "output limit exceeded: more than 100 bytes"},
+		{"timeout.json", "endless", "time limit exceeded: ran longer than 50ms"},
+	} {
+		r, err := LoadRubric(filepath.Join(dir, tc.rubric))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rep := r.Grade(filepath.Join(dir, tc.student))
+		if it := rep.Items[0]; it.Points != 0 || !strings.Contains(it.Detail, tc.want) {
+			t.Errorf("%s: got %v points: %s", tc.student, it.Points, it.Detail)
+		}
+	}
+}
+
+func TestConstructs(t *testing.T) {
+	for _, name := range []string{"For", "for each", "Function Real", "Do  Until"} {
+		if !IsConstruct(name) {
+			t.Errorf("expected %q to be a construct", name)
+		}
+	}
+	for _, name := range []string{"Goto", "Forever", ""} {
+		if IsConstruct(name) {
+			t.Errorf("expected %q not to be a construct", name)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
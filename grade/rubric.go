@@ -0,0 +1,115 @@
+// Package grade scores student submissions against an instructor's rubric.
+package grade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rubric lists the graded items for one assignment. It is read from a JSON file; input and output
+// files are relative to the rubric, so hidden test data can be kept away from students.
+type Rubric struct {
+	Program         string      `json:"program"`         // the file graded in each submission, e.g. "sales.gad"
+	MaxInstructions int         `json:"maxInstructions"` // optional limit on instructions per test run
+	MaxOutput       int         `json:"maxOutput"`       // optional limit on output bytes per test run; see [DefaultMaxOutput]
+	Timeout         float64     `json:"timeout"`         // optional limit in seconds per test run; see [DefaultTimeout]
+	Tests           []Test      `json:"tests"`
+	Constructs      []Construct `json:"constructs"`
+	Lint            *Lint       `json:"lint"`
+
+	dir string // the rubric's directory
+}
+
+// Test runs the program with the given input and compares its output.
+type Test struct {
+	Name    string  `json:"name"`
+	Points  float64 `json:"points"`
+	Input   string  `json:"input"`   // optional input file
+	Output  string  `json:"output"`  // expected output file
+	Partial bool    `json:"partial"` // award points for the fraction of expected lines produced
+	Hidden  bool    `json:"hidden"`  // don't reveal the name or output to students
+}
+
+// Construct awards points when the program uses a language construct; see [Constructs].
+type Construct struct {
+	Name    string  `json:"name"`
+	Points  float64 `json:"points"`
+	Require string  `json:"require"` // e.g. "For", "Function Real"
+}
+
+// Lint awards points when the program compiles cleanly and is already formatted.
+type Lint struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+}
+
+// LoadRubric reads and validates a rubric file.
+func LoadRubric(filename string) (*Rubric, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var r Rubric
+	dec := json.NewDecoder(strings.NewReader(string(buf)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	r.dir = filepath.Dir(filename)
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &r, nil
+}
+
+func (r *Rubric) validate() error {
+	if r.Program == "" {
+		return errors.New("missing program")
+	}
+	for i, t := range r.Tests {
+		if t.Output == "" {
+			return fmt.Errorf("test %d: missing output", i+1)
+		}
+		if t.Name == "" {
+			r.Tests[i].Name = strings.TrimSuffix(filepath.Base(t.Output), filepath.Ext(t.Output))
+		}
+	}
+	for i, c := range r.Constructs {
+		if !IsConstruct(c.Require) {
+			return fmt.Errorf("construct %d: unknown construct %q", i+1, c.Require)
+		}
+		if c.Name == "" {
+			r.Constructs[i].Name = "uses " + c.Require
+		}
+	}
+	if r.Lint != nil && r.Lint.Name == "" {
+		r.Lint.Name = "lint-free"
+	}
+	return nil
+}
+
+// MaxPoints returns the total points available.
+func (r *Rubric) MaxPoints() float64 {
+	var total float64
+	for _, t := range r.Tests {
+		total += t.Points
+	}
+	for _, c := range r.Constructs {
+		total += c.Points
+	}
+	if r.Lint != nil {
+		total += r.Lint.Points
+	}
+	return total
+}
+
+func (r *Rubric) path(file string) string {
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(r.dir, file)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 )
 
 var (
@@ -251,9 +252,9 @@ func (l *Lexer) parseCharacterLiteral() Result {
 	text := string(lit)
 	if v, err := strconv.Unquote(text); err != nil {
 		return Result{pos, ILLEGAL, text, fmt.Errorf("invalid character literal: %w", err)}
-	} else if len(v) < 1 {
+	} else if n := utf8.RuneCountInString(v); n < 1 {
 		return Result{pos, ILLEGAL, text, ErrCharacterTooShort}
-	} else if len(v) > 1 {
+	} else if n > 1 {
 		return Result{pos, ILLEGAL, text, ErrCharacterTooLong}
 	}
 	return Result{pos, CHR_LIT, text, nil}
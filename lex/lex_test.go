@@ -22,3 +22,27 @@ func TestLex(t *testing.T) {
 		}
 	}
 }
+
+func TestLexUnicode(t *testing.T) {
+	lexer := New(`Set name = "Zoë" + 'é'`)
+	var got []Result
+	for r := lexer.Lex(); r.Token != EOF; r = lexer.Lex() {
+		if r.Token == ILLEGAL {
+			t.Fatal(r.Error)
+		}
+		got = append(got, r)
+	}
+	last := got[len(got)-1]
+	if last.Token != CHR_LIT || last.Text != `'é'` {
+		t.Errorf("got %s %s, want CHR_LIT 'é'", last.Token, last.Text)
+	}
+	if last.Pos.Column != 19 {
+		t.Errorf("got column %d, want 19", last.Pos.Column)
+	}
+
+	for _, src := range []string{`'ëe'`, `''`} {
+		if r := New(src).Lex(); r.Token != ILLEGAL {
+			t.Errorf("%s: got %s, want ILLEGAL", src, r.Token)
+		}
+	}
+}
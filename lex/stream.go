@@ -1,5 +1,7 @@
 package lex
 
+import "unicode/utf8"
+
 type Stream struct {
 	buf    string
 	pos    int
@@ -14,10 +16,12 @@ func (s *Stream) Peek() byte {
 func (s *Stream) Next() byte {
 	ret := s.buf[s.pos]
 	s.pos++
-	s.column++
 	if ret == '\n' {
 		s.line++
 		s.column = 0
+	} else if utf8.RuneStart(ret) {
+		// columns count characters, not UTF-8 continuation bytes
+		s.column++
 	}
 	return ret
 }
@@ -90,6 +90,7 @@ const (
 
 	OUTPUTFILE
 	INPUTFILE
+	RANDOMFILE
 	APPENDMODE
 
 	CLASS
@@ -188,6 +189,7 @@ var tokens = []string{
 
 	OUTPUTFILE: "OUTPUTFILE",
 	INPUTFILE:  "INPUTFILE",
+	RANDOMFILE: "RANDOMFILE",
 	APPENDMODE: "APPENDMODE",
 
 	CLASS:   "CLASS",
@@ -244,6 +246,7 @@ var keywords = map[string]Token{
 	"Rename":     RENAME,
 	"OutputFile": OUTPUTFILE,
 	"InputFile":  INPUTFILE,
+	"RandomFile": RANDOMFILE,
 	"AppendMode": APPENDMODE,
 	"Class":      CLASS,
 	"Extends":    EXTENDS,
@@ -0,0 +1,68 @@
+package lib
+
+import "fmt"
+
+// ErrorKind classifies a RuntimeError, so it can be explained to the user.
+type ErrorKind int
+
+const (
+	GenericError ErrorKind = iota
+	IndexOutOfRange
+	DivisionByZero
+	BadConversion
+	FileNotOpen
+	Uninitialized
+	EndOfFile
+	Overflow
+	ArraySizeMismatch
+)
+
+var errorKindNames = [...]string{
+	"runtime error",
+	"index out of range",
+	"division by zero",
+	"bad conversion",
+	"file not open",
+	"uninitialized variable",
+	"end of file",
+	"arithmetic overflow",
+	"array size mismatch",
+}
+
+var errorKindHints = [...]string{
+	"",
+	"Indexes start at 0, so the last valid index is one less than the size or length.",
+	"Check that the divisor is not 0 before dividing, for example with an If statement.",
+	"Check that the value is a valid number first, for example with isInteger or isReal.",
+	"Open the file before reading or writing it, and don't use it again after Close.",
+	"Set the variable before using it, or give it an initial value when you Declare it.",
+	"Use eof in a While loop condition to stop reading when the file runs out of data.",
+	"Integer results must stay between -9223372036854775808 and 9223372036854775807; use Real for larger values.",
+	"A whole array can only be assigned an array of the same size; check the sizes it was declared with.",
+}
+
+func (k ErrorKind) String() string {
+	return errorKindNames[k]
+}
+
+// Hint returns beginner-friendly advice for fixing this kind of error, if any.
+func (k ErrorKind) Hint() string {
+	return errorKindHints[k]
+}
+
+// RuntimeError is an error of a known kind, raised by the library or the interpreter.
+type RuntimeError struct {
+	Kind ErrorKind
+	Msg  string
+}
+
+func (e RuntimeError) Error() string {
+	return e.Msg
+}
+
+// Errorf makes a RuntimeError of the given kind.
+func Errorf(kind ErrorKind, format string, args ...any) RuntimeError {
+	return RuntimeError{Kind: kind, Msg: fmt.Sprintf(format, args...)}
+}
+
+var errFileNotOpen = RuntimeError{Kind: FileNotOpen, Msg: "file not open"}
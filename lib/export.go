@@ -2,21 +2,24 @@ package lib
 
 import (
 	_ "embed"
+	"math"
 	"path/filepath"
 	"reflect"
 )
 
 type Func struct {
-	Name    string
-	FuncPtr reflect.Value
+	Name     string
+	Overload string // if set, the Gaddis name this function overloads
+	FuncPtr  reflect.Value
 }
 
-func CreateLibrary(iop IoProvider, rng RandContext) []Func {
+func CreateLibrary(iop IoProvider, rng RandContext, tc *TimeContext, pc ProgramContext) []Func {
 	ctx := ioContext{provider: iop}
 
 	entries := getEntries()
 	for i, v := range []any{
 		ctx.Display,
+		ctx.Print,
 		ctx.InputInteger,
 		ctx.InputReal,
 		ctx.InputString,
@@ -26,6 +29,7 @@ func CreateLibrary(iop IoProvider, rng RandContext) []Func {
 		ctx.OpenOutputFile,
 		ctx.OpenAppendFile,
 		ctx.OpenInputFile,
+		ctx.OpenRandomFile,
 		ctx.DeleteFile,
 		ctx.RenameFile,
 		CloseOutputFile,
@@ -38,7 +42,28 @@ func CreateLibrary(iop IoProvider, rng RandContext) []Func {
 		ReadCharacter,
 		ReadBoolean,
 
+		CloseRandomFile,
+		WriteRandomFile,
+		ReadRandomInteger,
+		ReadRandomReal,
+		ReadRandomString,
+		ReadRandomCharacter,
+		ReadRandomBoolean,
+
 		rng.random,
+
+		tc.today,
+		tc.currentYear,
+		tc.currentMonth,
+		tc.currentDay,
+		tc.currentHour,
+		tc.currentMinute,
+		tc.currentSecond,
+		tc.elapsedMilliseconds,
+
+		pc.argumentCount,
+		pc.argument,
+		pc.exit,
 	} {
 		entries[i].funcPtr = v
 	}
@@ -49,8 +74,9 @@ func CreateLibrary(iop IoProvider, rng RandContext) []Func {
 			panic(v.name)
 		}
 		ret[i] = Func{
-			Name:    v.name,
-			FuncPtr: reflect.ValueOf(v.funcPtr),
+			Name:     v.name,
+			Overload: overloads[v.name],
+			FuncPtr:  reflect.ValueOf(v.funcPtr),
 		}
 	}
 	return ret
@@ -61,19 +87,32 @@ func GetLibrary() []Func {
 
 	var ret []Func
 	for _, v := range entries {
-		if v.name == "random" {
-			v.funcPtr = random
+		if fn, ok := runtimeFuncs[v.name]; ok {
+			v.funcPtr = fn
 		} else if v.funcPtr == nil {
 			continue
 		}
 		ret = append(ret, Func{
-			Name:    v.name,
-			FuncPtr: reflect.ValueOf(v.funcPtr),
+			Name:     v.name,
+			Overload: overloads[v.name],
+			FuncPtr:  reflect.ValueOf(v.funcPtr),
 		})
 	}
 	return ret
 }
 
+type Const struct {
+	Name string
+	Val  any
+}
+
+// GetConstants returns the library's named constants.
+func GetConstants() []Const {
+	return []Const{
+		{"PI", math.Pi},
+	}
+}
+
 type entry struct {
 	name    string
 	funcPtr any
@@ -82,6 +121,7 @@ type entry struct {
 func getEntries() []entry {
 	return []entry{
 		{"Display", nil},
+		{"Print", nil},
 		{"InputInteger", nil},
 		{"InputReal", nil},
 		{"InputString", nil},
@@ -91,6 +131,7 @@ func getEntries() []entry {
 		{"OpenOutputFile", nil},
 		{"OpenAppendFile", nil},
 		{"OpenInputFile", nil},
+		{"OpenRandomFile", nil},
 		{"DeleteFile", nil},
 		{"RenameFile", nil},
 		{"CloseOutputFile", nil},
@@ -103,9 +144,33 @@ func getEntries() []entry {
 		{"ReadCharacter", nil},
 		{"ReadBoolean", nil},
 
+		{"CloseRandomFile", nil},
+		{"WriteRandomFile", nil},
+		{"ReadRandomInteger", nil},
+		{"ReadRandomReal", nil},
+		{"ReadRandomString", nil},
+		{"ReadRandomCharacter", nil},
+		{"ReadRandomBoolean", nil},
+
 		{"random", nil},
 
+		{"today", nil},
+		{"currentYear", nil},
+		{"currentMonth", nil},
+		{"currentDay", nil},
+		{"currentHour", nil},
+		{"currentMinute", nil},
+		{"currentSecond", nil},
+		{"elapsedMilliseconds", nil},
+
+		{"argumentCount", nil},
+		{"argument", nil},
+		{"exit", nil},
+
 		{"eof", eof},
+		{"eofRandomFile", eofRandomFile},
+		{"seek", seek},
+		{"recordCount", recordCount},
 
 		{"sqrt", sqrt},
 		{"pow", pow},
@@ -114,11 +179,31 @@ func getEntries() []entry {
 		{"round", round},
 		{"sin", sin},
 		{"tan", tan},
+		{"absInteger", absInteger},
+		{"maxInteger", maxInteger},
+		{"maxReal", maxReal},
+		{"minInteger", minInteger},
+		{"minReal", minReal},
+		{"log", log},
+		{"log10", log10},
+		{"exp", exp},
+		{"floor", floor},
+		{"ceil", ceil},
+		{"trunc", trunc},
+		{"atan", atan},
+		{"atan2", atan2},
+		{"acos", acos},
+		{"asin", asin},
 
 		{"toInteger", toInteger},
 		{"toReal", toReal},
 
 		{"currencyFormat", currencyFormat},
+		{"formatInteger", formatInteger},
+		{"formatNumber", formatNumber},
+		{"padLeft", padLeft},
+		{"padRight", padRight},
+		{"format", format},
 
 		{"length", length},
 		{"append", appendString},
@@ -128,6 +213,17 @@ func getEntries() []entry {
 		{"contains", contains},
 		{"insert", insertString},
 		{"delete", deleteString},
+		{"indexOf", indexOf},
+		{"lastIndexOf", lastIndexOf},
+		{"startsWith", startsWith},
+		{"endsWith", endsWith},
+		{"trim", trim},
+		{"replace", replace},
+		{"repeat", repeat},
+		{"split", split},
+		{"join", join},
+		{"ord", ord},
+		{"chr", chr},
 
 		{"stringToInteger", stringToInteger},
 		{"stringToReal", stringToReal},
@@ -140,32 +236,113 @@ func getEntries() []entry {
 		{"isUpper", isUpper},
 		{"isWhitespace", isWhitespace},
 
+		{"makeDate", makeDate},
+		{"yearOf", yearOf},
+		{"monthOf", monthOf},
+		{"dayOf", dayOf},
+		{"dayOfWeek", dayOfWeek},
+		{"addDays", addDays},
+		{"daysBetween", daysBetween},
+		{"formatDate", formatDate},
+
 		// NB: NOT part of Gaddis book, but seem like a glaring omission?
 		{"toString", toString},
 
+		{"size", size},
+		{"arrayLength", arrayLength},
+		{"sumIntegerArray", sumIntegerArray},
+		{"sumRealArray", sumRealArray},
+		{"maxIntegerArray", maxIntegerArray},
+		{"maxRealArray", maxRealArray},
+		{"minIntegerArray", minIntegerArray},
+		{"minRealArray", minRealArray},
+		{"averageIntegerArray", averageIntegerArray},
+		{"averageRealArray", averageRealArray},
+
 		// code gen helpers
 		{"$stringWithCharUpdate", stringWithCharUpdate},
+		{"$checkArraySize", checkArraySize},
 	}
 }
 
-var indexMap = mapEntries()
+// runtimeFuncs are the gogen runtime versions of context-dependent functions;
+// they provide the signatures of the corresponding library entries.
+var runtimeFuncs = map[string]any{
+	"random":              random,
+	"today":               today,
+	"currentYear":         currentYear,
+	"currentMonth":        currentMonth,
+	"currentDay":          currentDay,
+	"currentHour":         currentHour,
+	"currentMinute":       currentMinute,
+	"currentSecond":       currentSecond,
+	"elapsedMilliseconds": elapsedMilliseconds,
+	"argumentCount":       argumentCount,
+	"argument":            argument,
+	"exit":                exit,
+}
 
-func mapEntries() map[string]int {
+// overloads maps library functions onto the Gaddis name they overload.
+// Overloads are resolved by argument types during type checking.
+var overloads = map[string]string{
+	"eofRandomFile":       "eof",
+	"absInteger":          "abs",
+	"maxInteger":          "max",
+	"maxReal":             "max",
+	"minInteger":          "min",
+	"minReal":             "min",
+	"arrayLength":         "length",
+	"sumIntegerArray":     "sum",
+	"sumRealArray":        "sum",
+	"maxIntegerArray":     "max",
+	"maxRealArray":        "max",
+	"minIntegerArray":     "min",
+	"minRealArray":        "min",
+	"averageIntegerArray": "average",
+	"averageRealArray":    "average",
+}
+
+var indexMap, entryNames = mapEntries()
+
+func mapEntries() (map[string]int, []string) {
 	ret := map[string]int{}
+	var names []string
 	for i, v := range getEntries() {
 		ret[v.name] = i
+		names = append(names, v.name)
 	}
-	return ret
+	return ret, names
 }
 
 func IndexOf(name string) int {
-	i, ok := indexMap[name]
+	i, ok := LookupIndex(name)
 	if !ok {
 		panic(name)
 	}
 	return i
 }
 
+// NameAt returns the name of the function at the given index, which differs from the Gaddis name
+// of an overload; the inverse of [IndexOf].
+func NameAt(index int) string {
+	return entryNames[index]
+}
+
+// IsNamed reports whether the function at the given index has the given name, either its own or
+// the Gaddis name it overloads.
+func IsNamed(index int, name string) bool {
+	if index < 0 || index >= len(entryNames) {
+		return false
+	}
+	return entryNames[index] == name || overloads[entryNames[index]] == name
+}
+
+// LookupIndex returns the index of the named function in the library, if there is one.
+func LookupIndex(name string) (int, bool) {
+	i, ok := indexMap[name]
+	return i, ok
+}
+
 // BELOW: Used only by the gogen runtime.
 
 //go:embed io.go
@@ -174,6 +351,12 @@ var IoSource string
 //go:embed lib.go
 var LibSource string
 
+//go:embed time.go
+var TimeSource string
+
+//go:embed errors.go
+var ErrorsSource string
+
 type LibSrc struct {
 	Name string
 	Src  string
@@ -183,6 +366,8 @@ type LibSrc struct {
 var libSources = []LibSrc{
 	{"io.go", IoSource, 1000},
 	{"lib.go", LibSource, 2000},
+	{"time.go", TimeSource, 3000},
+	{"errors.go", ErrorsSource, 4000},
 }
 
 func SrcByName(filename string) *LibSrc {
@@ -3,19 +3,26 @@ package lib
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 type IoProvider interface {
 	Input() (string, error)
 	Output(string)
+	Print(string) // printer output
 	Dir() string
+	InputPolicy() InputPolicy
 }
 
 type ioContext struct {
@@ -23,6 +30,14 @@ type ioContext struct {
 }
 
 func (ctx ioContext) Display(args ...any) {
+	ctx.provider.Output(displayLine(args))
+}
+
+func (ctx ioContext) Print(args ...any) {
+	ctx.provider.Print(displayLine(args))
+}
+
+func displayLine(args []any) string {
 	var sb bytes.Buffer
 	tabCount := 0
 	for _, arg := range args {
@@ -37,59 +52,140 @@ func (ctx ioContext) Display(args ...any) {
 		}
 	}
 	sb.WriteRune('\n')
-	ctx.provider.Output(sb.String())
+	return sb.String()
 }
 
-func (ctx ioContext) InputInteger() int64 {
-	for {
-		ctx.provider.Output("integer> ")
-		input := ctx.readLine()
-		v, err := strconv.ParseInt(input, 10, 64)
-		if err == nil {
-			return v
+// InputMode selects how Input statements prompt for, and handle invalid, input.
+type InputMode int
+
+const (
+	DefaultInput = InputMode(iota) // prompt with the type, e.g. "integer> ", and retry invalid input
+	NoPrompt                       // no prompts; retry invalid input
+	CustomPrompt                   // prompt with InputPolicy.Prompt, and retry invalid input
+	FailFast                       // no prompts; invalid input is a runtime error
+)
+
+var inputModeNames = [...]string{
+	DefaultInput: "default",
+	NoPrompt:     "none",
+	CustomPrompt: "custom",
+	FailFast:     "failfast",
+}
+
+func (m InputMode) String() string { return inputModeNames[m] }
+
+// ParseInputMode returns the named input mode, if valid.
+func ParseInputMode(name string) (InputMode, bool) {
+	for i, n := range inputModeNames {
+		if n == name {
+			return InputMode(i), true
 		}
-		ctx.provider.Output("error, invalid integer, try again\n")
 	}
+	return DefaultInput, false
 }
 
-func (ctx ioContext) InputReal() float64 {
-	for {
-		ctx.provider.Output("real> ")
-		input := ctx.readLine()
-		v, err := strconv.ParseFloat(input, 64)
-		if err == nil {
-			return v
-		}
-		ctx.provider.Output("error, invalid real, try again\n")
+// InputPolicy controls the prompts and retry behavior of Input statements.
+type InputPolicy struct {
+	Mode   InputMode
+	Prompt string // for CustomPrompt; any %s is replaced with the type, e.g. "Enter a %s: "
+	Echo   bool   // echo each line of input to the output, producing a transcript
+}
+
+// Environment variables that configure the gogen runtime.
+const (
+	InputModeEnv = "GADDIS_INPUT"  // the InputMode name
+	PromptEnv    = "GADDIS_PROMPT" // the custom prompt
+	EchoEnv      = "GADDIS_ECHO"   // if set, echo input
+	PrintEnv     = "GADDIS_PRINT"  // the spool file for printer output; if unset, printer output goes to stdout
+)
+
+// InputPolicyEnv returns the environment settings that select the given policy.
+func InputPolicyEnv(p InputPolicy) []string {
+	ret := []string{InputModeEnv + "=" + p.Mode.String(), PromptEnv + "=" + p.Prompt}
+	if p.Echo {
+		ret = append(ret, EchoEnv+"=1")
+	}
+	return ret
+}
+
+func (p InputPolicy) prompt(typ string) string {
+	switch p.Mode {
+	case DefaultInput:
+		return typ + "> "
+	case CustomPrompt:
+		return strings.ReplaceAll(p.Prompt, "%s", typ)
+	default:
+		return ""
 	}
 }
 
+func (ctx ioContext) InputInteger() int64 {
+	var v int64
+	ctx.input("integer", "invalid integer", func(input string) (err error) {
+		v, err = strconv.ParseInt(input, 10, 64)
+		return
+	})
+	return v
+}
+
+func (ctx ioContext) InputReal() float64 {
+	var v float64
+	ctx.input("real", "invalid real", func(input string) (err error) {
+		v, err = strconv.ParseFloat(input, 64)
+		return
+	})
+	return v
+}
+
 func (ctx ioContext) InputString() string {
-	ctx.provider.Output("string> ")
-	input := ctx.readLine()
-	return input
+	var v string
+	ctx.input("string", "", func(input string) error {
+		v = input
+		return nil
+	})
+	return v
 }
 
-func (ctx ioContext) InputCharacter() byte {
-	for {
-		ctx.provider.Output("character> ")
-		input := ctx.readLine()
-		if len(input) == 1 {
-			return input[0]
+func (ctx ioContext) InputCharacter() rune {
+	var v rune
+	ctx.input("character", "input exactly 1 character", func(input string) error {
+		r, size := utf8.DecodeRuneInString(input)
+		if size == 0 || size != len(input) {
+			return errors.New("wrong length")
 		}
-		ctx.provider.Output("error, input exactly 1 character, try again\n")
-	}
+		v = r
+		return nil
+	})
+	return v
 }
 
 func (ctx ioContext) InputBoolean() bool {
+	var v bool
+	ctx.input("boolean", "invalid boolean", func(input string) (err error) {
+		v, err = strconv.ParseBool(input)
+		return
+	})
+	return v
+}
+
+// input prompts for and reads lines of input until parse accepts one, according to the input policy.
+func (ctx ioContext) input(typ string, problem string, parse func(string) error) {
+	policy := ctx.provider.InputPolicy()
 	for {
-		ctx.provider.Output("boolean> ")
+		if prompt := policy.prompt(typ); prompt != "" {
+			ctx.provider.Output(prompt)
+		}
 		input := ctx.readLine()
-		v, err := strconv.ParseBool(input)
-		if err == nil {
-			return v
+		if policy.Echo {
+			ctx.provider.Output(input + "\n")
+		}
+		if parse(input) == nil {
+			return
+		}
+		if policy.Mode == FailFast {
+			panic(Errorf(BadConversion, "%s: %q", problem, input))
 		}
-		ctx.provider.Output("error, invalid boolean, try again\n")
+		ctx.provider.Output("error, " + problem + ", try again\n")
 	}
 }
 
@@ -101,7 +197,14 @@ func (ctx ioContext) readLine() string {
 	return in
 }
 
-func (ctx ioContext) OpenOutputFile(file OutputFile, name string) OutputFile {
+// File formats, specified with `As` when opening a file.
+const (
+	GaddisFormat = int64(iota) // values are written as Gaddis literals, fields separated by tabs
+	CSVFormat                  // comma separated values
+	TextFormat                 // plain text, one value per line
+)
+
+func (ctx ioContext) OpenOutputFile(file OutputFile, name string, format int64) OutputFile {
 	if file.File != nil {
 		panic("file already open")
 	}
@@ -110,10 +213,10 @@ func (ctx ioContext) OpenOutputFile(file OutputFile, name string) OutputFile {
 	if err != nil {
 		panic(err)
 	}
-	return OutputFile{File: f}
+	return OutputFile{File: f, Format: format}
 }
 
-func (ctx ioContext) OpenAppendFile(file OutputFile, name string) OutputFile {
+func (ctx ioContext) OpenAppendFile(file OutputFile, name string, format int64) OutputFile {
 	if file.File != nil {
 		panic("file already open")
 	}
@@ -122,10 +225,10 @@ func (ctx ioContext) OpenAppendFile(file OutputFile, name string) OutputFile {
 	if err != nil {
 		panic(err)
 	}
-	return OutputFile{File: f, IsAppend: true}
+	return OutputFile{File: f, IsAppend: true, Format: format}
 }
 
-func (ctx ioContext) OpenInputFile(file InputFile, name string) InputFile {
+func (ctx ioContext) OpenInputFile(file InputFile, name string, format int64) InputFile {
 	if file.File != nil {
 		panic("file already open")
 	}
@@ -134,7 +237,33 @@ func (ctx ioContext) OpenInputFile(file InputFile, name string) InputFile {
 	if err != nil {
 		panic(err)
 	}
-	return InputFile{File: f, Reader: bufio.NewReader(f)}
+	ret := InputFile{File: f, Reader: bufio.NewReader(f), Name: name, Format: format, rec: &inputRecord{}}
+	if format == CSVFormat {
+		ret.rec.csv = csv.NewReader(ret.Reader)
+		ret.rec.csv.FieldsPerRecord = -1
+	}
+	return ret
+}
+
+// OpenRandomFile opens (or creates) a binary file of fixed-size records for reading and writing.
+func (ctx ioContext) OpenRandomFile(file RandomFile, name string, recordSize int64) RandomFile {
+	if file.File != nil {
+		panic("file already open")
+	}
+	if recordSize <= 0 {
+		panic(fmt.Sprintf("%s: record size %d must be positive", name, recordSize))
+	}
+	filename := filepath.Join(ctx.provider.Dir(), string(name))
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		panic(err)
+	}
+	ret := RandomFile{File: f, Name: name, RecordSize: recordSize, rec: &randomRecord{}}
+	if n := ret.size(); n%recordSize != 0 {
+		_ = f.Close()
+		panic(fmt.Errorf("%s: file size %d is not a multiple of the record size %d", name, n, recordSize))
+	}
+	return ret
 }
 
 func (ctx ioContext) DeleteFile(name string) {
@@ -156,7 +285,7 @@ func (ctx ioContext) RenameFile(oldName string, newName string) {
 
 func CloseOutputFile(file OutputFile) {
 	if file.File == nil {
-		panic("file not open")
+		panic(errFileNotOpen)
 	}
 	err := file.File.Close()
 	file.File = nil
@@ -167,7 +296,7 @@ func CloseOutputFile(file OutputFile) {
 
 func CloseInputFile(file InputFile) {
 	if file.File == nil {
-		panic("file not open")
+		panic(errFileNotOpen)
 	}
 	err := file.File.Close()
 	file.File = nil
@@ -177,124 +306,300 @@ func CloseInputFile(file InputFile) {
 	}
 }
 
+func CloseRandomFile(file RandomFile) {
+	if file.File == nil {
+		panic(errFileNotOpen)
+	}
+	err := file.File.Close()
+	file.File = nil
+	if err != nil {
+		panic(err)
+	}
+}
+
 func WriteFile(of OutputFile, args ...any) {
 	if of.File == nil {
-		panic("file not open")
-	}
-	file := of.File
-	for i, arg := range args {
-		if i > 0 {
-			if _, err := file.WriteString("\t"); err != nil {
-				panic(err)
-			}
+		panic(errFileNotOpen)
+	}
+	var err error
+	switch of.Format {
+	case CSVFormat:
+		fields := make([]string, len(args))
+		for i, arg := range args {
+			fields[i] = toString(arg)
 		}
-		var err error
-		switch typedArg := arg.(type) {
-		case bool:
-			if typedArg {
-				_, err = file.WriteString("True")
-			} else {
-				_, err = file.WriteString("False")
-			}
-		case string:
-			_, err = file.WriteString(strconv.Quote(string(typedArg)))
-		case byte:
-			_, err = file.WriteString(strconv.QuoteRune(rune(typedArg)))
-		case int64:
-			_, err = file.WriteString(strconv.FormatInt(typedArg, 10))
-		case float64:
-			_, err = file.WriteString(strconv.FormatFloat(typedArg, 'g', -1, 64))
-		default:
-			panic(typedArg)
+		w := csv.NewWriter(of.File)
+		_ = w.Write(fields)
+		w.Flush()
+		err = w.Error()
+	case TextFormat:
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
 		}
-		if err != nil {
-			panic(err)
+		sb.WriteByte('\n')
+		_, err = of.File.WriteString(sb.String())
+	default:
+		fields := make([]string, len(args))
+		for i, arg := range args {
+			fields[i] = gaddisLiteral(arg)
 		}
+		_, err = fmt.Fprintln(of.File, strings.Join(fields, "\t"))
 	}
-	if _, err := fmt.Fprintln(file); err != nil {
+	if err != nil {
 		panic(err)
 	}
 }
 
-func ReadInteger(file InputFile, field bool) int64 {
-	if file.File == nil {
-		panic("file not open")
+func gaddisLiteral(arg any) string {
+	switch typedArg := arg.(type) {
+	case bool:
+		if typedArg {
+			return "True"
+		} else {
+			return "False"
+		}
+	case string:
+		return strconv.Quote(typedArg)
+	case rune:
+		return strconv.QuoteRune(typedArg)
+	case int64:
+		return strconv.FormatInt(typedArg, 10)
+	case float64:
+		return strconv.FormatFloat(typedArg, 'g', -1, 64)
+	default:
+		panic(typedArg)
 	}
-	input := scanValue(file, field)
+}
+
+func ReadInteger(file InputFile, field int64, count int64) int64 {
+	input := strings.TrimSpace(readField(file, field, count))
 	v, err := strconv.ParseInt(input, 10, 64)
 	if err != nil {
-		panic(err)
+		panic(file.fieldError(field, "Integer", input))
 	}
 	return v
 }
 
-func ReadReal(file InputFile, field bool) float64 {
-	if file.File == nil {
-		panic("file not open")
-	}
-	input := scanValue(file, field)
+func ReadReal(file InputFile, field int64, count int64) float64 {
+	input := strings.TrimSpace(readField(file, field, count))
 	v, err := strconv.ParseFloat(input, 64)
 	if err != nil {
-		panic(err)
+		panic(file.fieldError(field, "Real", input))
 	}
 	return v
 }
 
-func ReadString(file InputFile, field bool) string {
-	input := scanValue(file, field)
+func ReadString(file InputFile, field int64, count int64) string {
+	input := readField(file, field, count)
+	if file.Format != GaddisFormat {
+		return input
+	}
 	v, err := strconv.Unquote(input)
 	if err != nil {
-		panic(err)
+		panic(file.fieldError(field, "String", input))
 	}
 	return v
 }
 
-func ReadCharacter(file InputFile, field bool) byte {
-	input := scanValue(file, field)
-	v, err := strconv.Unquote(input)
-	if err != nil {
-		panic(err)
+func ReadCharacter(file InputFile, field int64, count int64) rune {
+	input := readField(file, field, count)
+	v := input
+	var err error
+	if file.Format == GaddisFormat {
+		v, err = strconv.Unquote(input)
 	}
-	if len(v) != 0 {
-		panic("invalid character")
+	r, size := utf8.DecodeRuneInString(v)
+	if err != nil || size == 0 || size != len(v) {
+		panic(file.fieldError(field, "Character", input))
 	}
-	return v[0]
+	return r
 }
 
-func ReadBoolean(file InputFile, field bool) bool {
-	input := scanValue(file, field)
+func ReadBoolean(file InputFile, field int64, count int64) bool {
+	input := strings.TrimSpace(readField(file, field, count))
 	v, err := strconv.ParseBool(input)
 	if err != nil {
-		panic(err)
+		panic(file.fieldError(field, "Boolean", input))
 	}
 	return v
 }
 
-func scanValue(file InputFile, field bool) string {
+// readField returns the given field of the current record; reading field 0 advances to the next record,
+// which must have exactly count fields.
+func readField(file InputFile, field int64, count int64) string {
 	if file.File == nil {
-		panic("file not open")
+		panic(errFileNotOpen)
 	}
-	delim := byte('\n')
-	if field {
-		delim = '\t'
+	rec := file.rec
+	if field == 0 {
+		file.readRecord()
+		if file.Format == TextFormat && count != 1 {
+			panic(fmt.Errorf("%s:%d: Text files have one value per line, Read expected %d", file.Name, rec.line, count))
+		}
+		if int64(len(rec.fields)) != count {
+			panic(fmt.Errorf("%s:%d: record %d has %d fields, Read expected %d", file.Name, rec.line, rec.num, len(rec.fields), count))
+		}
 	}
-	v, err := file.Reader.ReadString(delim)
-	if err != nil {
+	return rec.fields[field]
+}
+
+func (file InputFile) readRecord() {
+	rec := file.rec
+	if rec.csv != nil {
+		fields, err := rec.csv.Read()
+		if err == io.EOF {
+			panic(Errorf(EndOfFile, "%s: end of file reading record %d", file.Name, rec.num+1))
+		} else if err != nil {
+			panic(fmt.Errorf("%s: %w", file.Name, err))
+		}
+		rec.num++
+		rec.line, _ = rec.csv.FieldPos(0)
+		rec.fields = fields
+		return
+	}
+
+	line, err := file.Reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		panic(Errorf(EndOfFile, "%s: end of file reading record %d", file.Name, rec.num+1))
+	} else if err == io.EOF && file.Format == GaddisFormat {
+		panic(fmt.Errorf("%s:%d: record %d is missing a final newline", file.Name, rec.num+1, rec.num+1))
+	} else if err != nil && err != io.EOF {
 		panic(err)
 	}
-	if field && strings.ContainsRune(v, '\n') {
-		panic(errors.New("unexpected record separator reading field"))
+	rec.num++
+	rec.line = rec.num
+	line = strings.TrimSuffix(line, "\n")
+	if file.Format == TextFormat {
+		rec.fields = []string{strings.TrimSuffix(line, "\r")}
+		return
 	}
-	if !field && strings.ContainsRune(v, '\t') {
-		panic(errors.New("unexpected field separator reading record"))
+	rec.fields = strings.Split(line, "\t")
+	for i := range rec.fields {
+		rec.fields[i] = strings.TrimSpace(rec.fields[i])
 	}
+}
+
+// WriteRandomFile writes a record at the current position, replacing the existing record if any,
+// and advances to the next record.
+func WriteRandomFile(file RandomFile, args ...any) {
+	if file.File == nil {
+		panic(errFileNotOpen)
+	}
+	num := file.position()
+	buf := make([]byte, 0, file.RecordSize)
+	for _, arg := range args {
+		switch typedArg := arg.(type) {
+		case bool:
+			if typedArg {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		case string:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(typedArg)))
+			buf = append(buf, typedArg...)
+		case rune:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(typedArg))
+		case int64:
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(typedArg))
+		case float64:
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(typedArg))
+		default:
+			panic(typedArg)
+		}
+	}
+	if int64(len(buf)) > file.RecordSize {
+		panic(fmt.Errorf("%s: record %d needs %d bytes, larger than the record size %d", file.Name, num, len(buf), file.RecordSize))
+	}
+	buf = buf[:file.RecordSize] // zero pad
+	if _, err := file.File.Write(buf); err != nil {
+		panic(err)
+	}
+}
+
+func ReadRandomInteger(file RandomFile, field int64) int64 {
+	return int64(binary.LittleEndian.Uint64(readRandomField(file, field, "Integer", 8)))
+}
 
-	return strings.TrimSpace(v)
+func ReadRandomReal(file RandomFile, field int64) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(readRandomField(file, field, "Real", 8)))
+}
+
+func ReadRandomString(file RandomFile, field int64) string {
+	n := binary.LittleEndian.Uint32(readRandomField(file, field, "String", 4))
+	return string(file.rec.take(file, field, "String", int64(n)))
+}
+
+func ReadRandomCharacter(file RandomFile, field int64) rune {
+	return rune(binary.LittleEndian.Uint32(readRandomField(file, field, "Character", 4)))
+}
+
+func ReadRandomBoolean(file RandomFile, field int64) bool {
+	return readRandomField(file, field, "Boolean", 1)[0] != 0
+}
+
+// readRandomField consumes n bytes of the given field of the current record; reading field 0 reads the
+// record at the current position and advances to the next record.
+func readRandomField(file RandomFile, field int64, typ string, n int64) []byte {
+	if file.File == nil {
+		panic(errFileNotOpen)
+	}
+	rec := file.rec
+	if field == 0 {
+		rec.num = file.position()
+		if rec.num >= file.recordCount() {
+			panic(Errorf(EndOfFile, "%s: end of file reading record %d", file.Name, rec.num))
+		}
+		rec.buf = make([]byte, file.RecordSize)
+		if _, err := io.ReadFull(file.File, rec.buf); err != nil {
+			panic(err)
+		}
+	}
+	return rec.take(file, field, typ, n)
+}
+
+func (rec *randomRecord) take(file RandomFile, field int64, typ string, n int64) []byte {
+	if n > int64(len(rec.buf)) {
+		panic(fmt.Errorf("%s: record %d field %d: not enough data left in the record to read %s", file.Name, rec.num, field+1, typ))
+	}
+	ret := rec.buf[:n]
+	rec.buf = rec.buf[n:]
+	return ret
+}
+
+// seek moves a random access file to the given record; records are numbered from 0, like array elements.
+// Seeking to recordCount positions the file to append a new record.
+func seek(file RandomFile, record int64) {
+	if file.File == nil {
+		panic(errFileNotOpen)
+	}
+	if n := file.recordCount(); record < 0 || record > n {
+		panic(Errorf(IndexOutOfRange, "seek: record %d out of range [0:%d]", record, n))
+	}
+	if _, err := file.File.Seek(record*file.RecordSize, io.SeekStart); err != nil {
+		panic(err)
+	}
+}
+
+// recordCount returns the number of records in a random access file.
+func recordCount(file RandomFile) int64 {
+	if file.File == nil {
+		panic(errFileNotOpen)
+	}
+	return file.recordCount()
+}
+
+func eofRandomFile(file RandomFile) bool {
+	if file.File == nil {
+		panic(errFileNotOpen)
+	}
+	return file.position() >= file.recordCount()
 }
 
 func eof(file InputFile) bool {
 	if file.File == nil {
-		panic("file not open")
+		panic(errFileNotOpen)
 	}
 	_, err := file.Reader.Peek(1)
 	return err == io.EOF
@@ -308,6 +613,7 @@ var TabDisplay = tabDisplay{}
 type OutputFile struct {
 	File     *os.File
 	IsAppend bool
+	Format   int64
 }
 
 func (of *OutputFile) String() string {
@@ -326,6 +632,63 @@ type AppendFile = OutputFile
 type InputFile struct {
 	File   *os.File
 	Reader *bufio.Reader
+	Name   string
+	Format int64
+	rec    *inputRecord
+}
+
+// inputRecord tracks the current record, shared by all copies of an InputFile.
+type inputRecord struct {
+	num    int         // 1-based record number
+	line   int         // 1-based line number where the record starts
+	fields []string    // the fields of the current record
+	csv    *csv.Reader // only for CSV files
+}
+
+func (file InputFile) fieldError(field int64, typ string, input string) error {
+	return Errorf(BadConversion, "%s:%d: record %d field %d: expected %s, got %s", file.Name, file.rec.line, file.rec.num, field+1, typ, input)
+}
+
+// RandomFile is a binary file of fixed-size records, which can be read and written in any order.
+type RandomFile struct {
+	File       *os.File
+	Name       string
+	RecordSize int64
+	rec        *randomRecord
+}
+
+// randomRecord tracks the record being read, shared by all copies of a RandomFile.
+type randomRecord struct {
+	num int64  // 0-based number of the record being read
+	buf []byte // the unread remainder of the record
+}
+
+func (file RandomFile) size() int64 {
+	st, err := file.File.Stat()
+	if err != nil {
+		panic(err)
+	}
+	return st.Size()
+}
+
+func (file RandomFile) recordCount() int64 {
+	return file.size() / file.RecordSize
+}
+
+// position returns the number of the record at the current file offset.
+func (file RandomFile) position() int64 {
+	off, err := file.File.Seek(0, io.SeekCurrent)
+	if err != nil {
+		panic(err)
+	}
+	return off / file.RecordSize
+}
+
+func (rf *RandomFile) String() string {
+	if rf == nil {
+		return "<nil>"
+	}
+	return "<RandomFile>"
 }
 
 func (of *InputFile) String() string {
@@ -338,7 +701,8 @@ func (of *InputFile) String() string {
 // BELOW: Used only by the gogen runtime.
 
 type defaultIo struct {
-	in *bufio.Scanner
+	in     *bufio.Scanner
+	policy InputPolicy
 }
 
 func (dio defaultIo) Input() (string, error) {
@@ -357,14 +721,56 @@ func (dio defaultIo) Output(text string) {
 	_ = os.Stdout.Sync()
 }
 
+func (dio defaultIo) InputPolicy() InputPolicy {
+	return dio.policy
+}
+
+func inputPolicyFromEnv() InputPolicy {
+	mode, _ := ParseInputMode(os.Getenv(InputModeEnv))
+	return InputPolicy{Mode: mode, Prompt: os.Getenv(PromptEnv), Echo: os.Getenv(EchoEnv) != ""}
+}
+
+// the printer spool file, opened on the first Print and closed at exit
+var (
+	printOnce sync.Once
+	printFile *os.File
+	printErr  error
+)
+
+func (dio defaultIo) Print(text string) {
+	name := os.Getenv(PrintEnv)
+	if name == "" {
+		dio.Output(text)
+		return
+	}
+	printOnce.Do(func() {
+		printFile, printErr = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	})
+	if printErr != nil {
+		panic(printErr)
+	}
+	if _, err := printFile.WriteString(text); err != nil {
+		panic(err)
+	}
+}
+
+// closePrinter closes the printer spool file, if Print opened one.
+func closePrinter() {
+	if printFile != nil {
+		_ = printFile.Close()
+		printFile = nil
+	}
+}
+
 func (dio defaultIo) Dir() string {
 	return "."
 }
 
 var (
-	ioCtx = ioContext{provider: defaultIo{in: bufio.NewScanner(os.Stdin)}}
+	ioCtx = ioContext{provider: defaultIo{in: bufio.NewScanner(os.Stdin), policy: inputPolicyFromEnv()}}
 
 	Display        = ioCtx.Display
+	Print          = ioCtx.Print
 	InputInteger   = ioCtx.InputInteger
 	InputReal      = ioCtx.InputReal
 	InputString    = ioCtx.InputString
@@ -374,6 +780,7 @@ var (
 	OpenOutputFile = ioCtx.OpenOutputFile
 	OpenAppendFile = ioCtx.OpenAppendFile
 	OpenInputFile  = ioCtx.OpenInputFile
+	OpenRandomFile = ioCtx.OpenRandomFile
 	DeleteFile     = ioCtx.DeleteFile
 	RenameFile     = ioCtx.RenameFile
 )
@@ -2,8 +2,12 @@ package lib
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -18,13 +22,23 @@ func makeIoContext(in string) (ioContext, *testProvider) {
 
 type testProvider struct {
 	outbuf bytes.Buffer
+	prnbuf bytes.Buffer
 	input  []string
+	policy InputPolicy
+}
+
+func (tp *testProvider) InputPolicy() InputPolicy {
+	return tp.policy
 }
 
 func (tp *testProvider) Output(s string) {
 	tp.outbuf.WriteString(s)
 }
 
+func (tp *testProvider) Print(s string) {
+	tp.prnbuf.WriteString(s)
+}
+
 func (tp *testProvider) Input() (string, error) {
 	if len(tp.input) >= 0 {
 		in := tp.input[0]
@@ -55,6 +69,38 @@ func TestDisplay(t *testing.T) {
 	assertEqual(t, "the score is 17 to 21.5 is False\n", tp.String())
 }
 
+func TestPrint(t *testing.T) {
+	ctx, tp := makeIoContext("")
+	ctx.Display("screen")
+	ctx.Print("Name", TabDisplay, "Total")
+	assertEqual(t, "screen\n", tp.String())
+	assertEqual(t, "Name    Total\n", tp.prnbuf.String())
+}
+
+func TestPrintSpool(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "spool.txt")
+	t.Setenv(PrintEnv, name)
+	t.Cleanup(func() {
+		closePrinter()
+		printOnce, printErr = sync.Once{}, nil
+	})
+
+	var dio defaultIo
+	dio.Print("one\n")
+	// the spool stays open, so a renamed file keeps getting the output
+	if err := os.Rename(name, filepath.Join(dir, "moved.txt")); err != nil {
+		t.Fatal(err)
+	}
+	dio.Print("two\n")
+	closePrinter()
+	got, err := os.ReadFile(filepath.Join(dir, "moved.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "one\ntwo\n", string(got))
+}
+
 func TestInputInteger(t *testing.T) {
 	ctx, tp := makeIoContext("not a number\n123\n")
 	got := ctx.InputInteger()
@@ -79,6 +125,14 @@ func TestInputString(t *testing.T) {
 	assertEqual(t, "string> ", tp.String())
 }
 
+func TestInputStringUnicode(t *testing.T) {
+	ctx, tp := makeIoContext("José\n")
+	got := ctx.InputString()
+	assertEqual(t, "José", got)
+	tp.assertEmpty(t)
+	assertEqual(t, "string> ", tp.String())
+}
+
 func TestInputCharacter(t *testing.T) {
 	ctx, tp := makeIoContext("\ntrue\nc")
 	got := ctx.InputCharacter()
@@ -87,6 +141,15 @@ func TestInputCharacter(t *testing.T) {
 	assertEqual(t, "character> error, input exactly 1 character, try again\ncharacter> error, input exactly 1 character, try again\ncharacter> ", tp.String())
 }
 
+func TestInputCharacterUnicode(t *testing.T) {
+	// a multibyte character is one character, but two of them are not
+	ctx, tp := makeIoContext("ëë\në")
+	got := ctx.InputCharacter()
+	assertEqual(t, 'ë', got)
+	tp.assertEmpty(t)
+	assertEqual(t, "character> error, input exactly 1 character, try again\ncharacter> ", tp.String())
+}
+
 func TestInputBoolean(t *testing.T) {
 	ctx, tp := makeIoContext("not a boolean\ntrue\n")
 	got := ctx.InputBoolean()
@@ -95,9 +158,232 @@ func TestInputBoolean(t *testing.T) {
 	assertEqual(t, "boolean> error, invalid boolean, try again\nboolean> ", tp.String())
 }
 
+func TestInputPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy InputPolicy
+		want   string
+	}{
+		{InputPolicy{}, "integer> error, invalid integer, try again\ninteger> "},
+		{InputPolicy{Mode: NoPrompt}, "error, invalid integer, try again\n"},
+		{InputPolicy{Mode: CustomPrompt, Prompt: "Enter an %s: "}, "Enter an integer: error, invalid integer, try again\nEnter an integer: "},
+		{InputPolicy{Echo: true}, "integer> x\nerror, invalid integer, try again\ninteger> 7\n"},
+		{InputPolicy{Mode: NoPrompt, Echo: true}, "x\nerror, invalid integer, try again\n7\n"},
+	} {
+		ctx, tp := makeIoContext("x\n7\n")
+		tp.policy = tc.policy
+		assertEqual(t, int64(7), ctx.InputInteger())
+		assertEqual(t, tc.want, tp.String())
+	}
+}
+
+func TestInputFailFast(t *testing.T) {
+	ctx, tp := makeIoContext("x\n7\n")
+	tp.policy = InputPolicy{Mode: FailFast}
+	got := func() (ret string) {
+		defer func() { ret = fmt.Sprint(recover()) }()
+		ctx.InputInteger()
+		return ""
+	}()
+	assertEqual(t, `invalid integer: "x"`, got)
+	assertEqual(t, "", tp.String())
+}
+
 func assertEqual[T comparable](t *testing.T, want T, got T) {
 	t.Helper()
 	if want != got {
 		t.Errorf("want=%v, got=%v", want, got)
 	}
 }
+
+type dirProvider string
+
+func (dirProvider) Input() (string, error)   { return "", io.EOF }
+func (dirProvider) Output(string)            {}
+func (dirProvider) Print(string)             {}
+func (dirProvider) InputPolicy() InputPolicy { return InputPolicy{} }
+func (dp dirProvider) Dir() string           { return string(dp) }
+
+func openTestFile(t *testing.T, content string) InputFile {
+	t.Helper()
+	return openTestFileFormat(t, content, GaddisFormat)
+}
+
+func openTestFileFormat(t *testing.T, content string, format int64) InputFile {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	ctx := ioContext{provider: dirProvider(dir)}
+	return ctx.OpenInputFile(InputFile{}, "data.txt", format)
+}
+
+func TestReadRecords(t *testing.T) {
+	file := openTestFile(t, "1\t2.5\t\"three\"\n'c'\tTrue\n")
+	defer CloseInputFile(file)
+	assertEqual(t, int64(1), ReadInteger(file, 0, 3))
+	assertEqual(t, 2.5, ReadReal(file, 1, 3))
+	assertEqual(t, "three", ReadString(file, 2, 3))
+	assertEqual(t, 'c', ReadCharacter(file, 0, 2))
+	assertEqual(t, true, ReadBoolean(file, 1, 2))
+	assertEqual(t, true, eof(file))
+}
+
+func TestReadRecordErrors(t *testing.T) {
+	for _, tc := range []struct {
+		content string
+		read    func(file InputFile)
+		want    string
+	}{
+		{"1\t2\n", func(f InputFile) { ReadInteger(f, 0, 3) }, "data.txt:1: record 1 has 2 fields, Read expected 3"},
+		{"1\n1\t2\t3\n", func(f InputFile) { ReadInteger(f, 0, 1); ReadInteger(f, 0, 2) }, "data.txt:2: record 2 has 3 fields, Read expected 2"},
+		{"1\n", func(f InputFile) { ReadInteger(f, 0, 1); ReadInteger(f, 0, 1) }, "data.txt: end of file reading record 2"},
+		{"1", func(f InputFile) { ReadInteger(f, 0, 1) }, "data.txt:1: record 1 is missing a final newline"},
+		{"1\tx\n", func(f InputFile) { ReadInteger(f, 0, 2); ReadReal(f, 1, 2) }, "data.txt:1: record 1 field 2: expected Real, got x"},
+	} {
+		file := openTestFile(t, tc.content)
+		got := func() (ret string) {
+			defer func() { ret = fmt.Sprint(recover()) }()
+			tc.read(file)
+			return ""
+		}()
+		CloseInputFile(file)
+		if got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestFileFormatRoundTrip(t *testing.T) {
+	const tricky = "José, \"quoted\"\tvalue"
+	for _, format := range []int64{GaddisFormat, CSVFormat} {
+		dir := t.TempDir()
+		ctx := ioContext{provider: dirProvider(dir)}
+		of := ctx.OpenOutputFile(OutputFile{}, "data", format)
+		WriteFile(of, int64(42), 1.5, tricky, 'é', true)
+		WriteFile(of, "line\nbreak")
+		CloseOutputFile(of)
+
+		file := ctx.OpenInputFile(InputFile{}, "data", format)
+		assertEqual(t, int64(42), ReadInteger(file, 0, 5))
+		assertEqual(t, 1.5, ReadReal(file, 1, 5))
+		assertEqual(t, tricky, ReadString(file, 2, 5))
+		assertEqual(t, 'é', ReadCharacter(file, 3, 5))
+		assertEqual(t, true, ReadBoolean(file, 4, 5))
+		assertEqual(t, "line\nbreak", ReadString(file, 0, 1))
+		assertEqual(t, true, eof(file))
+		CloseInputFile(file)
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	dir := t.TempDir()
+	ctx := ioContext{provider: dirProvider(dir)}
+	of := ctx.OpenOutputFile(OutputFile{}, "data", TextFormat)
+	WriteFile(of, "Total: ", int64(3))
+	WriteFile(of, "  padded, with\ttab  ")
+	CloseOutputFile(of)
+
+	file := ctx.OpenInputFile(InputFile{}, "data", TextFormat)
+	assertEqual(t, "Total: 3", ReadString(file, 0, 1))
+	assertEqual(t, "  padded, with\ttab  ", ReadString(file, 0, 1))
+	assertEqual(t, true, eof(file))
+	CloseInputFile(file)
+
+	// A missing final newline is fine in a text file.
+	file = openTestFileFormat(t, "1\n2", TextFormat)
+	assertEqual(t, int64(1), ReadInteger(file, 0, 1))
+	assertEqual(t, int64(2), ReadInteger(file, 0, 1))
+	CloseInputFile(file)
+}
+
+func TestFileFormatErrors(t *testing.T) {
+	for _, tc := range []struct {
+		content string
+		format  int64
+		read    func(file InputFile)
+		want    string
+	}{
+		{"1,2\n", CSVFormat, func(f InputFile) { ReadInteger(f, 0, 3) }, "data.txt:1: record 1 has 2 fields, Read expected 3"},
+		{"\"a\nb\"\n1,x\n", CSVFormat, func(f InputFile) { ReadString(f, 0, 1); ReadInteger(f, 0, 2); ReadInteger(f, 1, 2) }, "data.txt:3: record 2 field 2: expected Integer, got x"},
+		{"\"oops\n", CSVFormat, func(f InputFile) { ReadString(f, 0, 1) }, "data.txt: parse error on line 1, column 7: extraneous or missing \" in quoted-field"},
+		{"1\n", TextFormat, func(f InputFile) { ReadInteger(f, 0, 2) }, "data.txt:1: Text files have one value per line, Read expected 2"},
+		{"ab\n", TextFormat, func(f InputFile) { ReadCharacter(f, 0, 1) }, "data.txt:1: record 1 field 1: expected Character, got ab"},
+	} {
+		file := openTestFileFormat(t, tc.content, tc.format)
+		got := func() (ret string) {
+			defer func() { ret = fmt.Sprint(recover()) }()
+			tc.read(file)
+			return ""
+		}()
+		CloseInputFile(file)
+		if got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestRandomFile(t *testing.T) {
+	dir := t.TempDir()
+	ctx := ioContext{provider: dirProvider(dir)}
+	file := ctx.OpenRandomFile(RandomFile{}, "data", 32)
+	for i := int64(0); i < 3; i++ {
+		WriteRandomFile(file, i, 1.5*float64(i), fmt.Sprint("rec", i), rune('a'+i), i%2 == 0)
+	}
+	assertEqual(t, int64(3), recordCount(file))
+	assertEqual(t, true, eofRandomFile(file))
+
+	// Update record 1 in place.
+	seek(file, 1)
+	WriteRandomFile(file, int64(42), 0.25, "Zoë", 'ë', true)
+	assertEqual(t, int64(3), recordCount(file))
+	CloseRandomFile(file)
+
+	file = ctx.OpenRandomFile(RandomFile{}, "data", 32)
+	defer CloseRandomFile(file)
+	assertEqual(t, false, eofRandomFile(file))
+	seek(file, 1)
+	assertEqual(t, int64(42), ReadRandomInteger(file, 0))
+	assertEqual(t, 0.25, ReadRandomReal(file, 1))
+	assertEqual(t, "Zoë", ReadRandomString(file, 2))
+	assertEqual(t, 'ë', ReadRandomCharacter(file, 3))
+	assertEqual(t, true, ReadRandomBoolean(file, 4))
+	assertEqual(t, int64(2), ReadRandomInteger(file, 0))
+	assertEqual(t, true, eofRandomFile(file))
+	seek(file, 0)
+	assertEqual(t, int64(0), ReadRandomInteger(file, 0))
+	assertEqual(t, 0.0, ReadRandomReal(file, 1))
+}
+
+func TestRandomFileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		run  func(file RandomFile)
+		want string
+	}{
+		{"too big", func(f RandomFile) { WriteRandomFile(f, "a long string that does not fit") }, "data: record 0 needs 35 bytes, larger than the record size 16"},
+		{"eof", func(f RandomFile) { WriteRandomFile(f, int64(1)); ReadRandomInteger(f, 0) }, "data: end of file reading record 1"},
+		{"seek", func(f RandomFile) { seek(f, 1) }, "seek: record 1 out of range [0:0]"},
+		{"short", func(f RandomFile) {
+			WriteRandomFile(f, int64(1))
+			seek(f, 0)
+			ReadRandomInteger(f, 0)
+			ReadRandomInteger(f, 1)
+			ReadRandomInteger(f, 2)
+		}, "data: record 0 field 3: not enough data left in the record to read Integer"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := ioContext{provider: dirProvider(t.TempDir())}
+			file := ctx.OpenRandomFile(RandomFile{}, "data", 16)
+			defer CloseRandomFile(file)
+			got := func() (ret string) {
+				defer func() { ret = fmt.Sprint(recover()) }()
+				tc.run(file)
+				return ""
+			}()
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
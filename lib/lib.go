@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"os"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
 type RandContext struct {
@@ -19,16 +23,142 @@ func (ctx RandContext) random(lo int64, hi int64) int64 {
 	return lo + ctx.Rng.Int63n(hi-lo+1)
 }
 
+// ProgramContext holds the program's command-line arguments.
+type ProgramContext struct {
+	Args []string
+}
+
+func (ctx ProgramContext) argumentCount() int64 {
+	return int64(len(ctx.Args))
+}
+
+func (ctx ProgramContext) argument(i int64) string {
+	if i < 0 || i >= int64(len(ctx.Args)) {
+		panic(Errorf(IndexOutOfRange, "argument: index %d out of range [0:%d]", i, len(ctx.Args)))
+	}
+	return ctx.Args[i]
+}
+
+// exit ends the program with the given exit code.
+// NB: the VM never calls this; asmgen compiles exit into a halt instruction.
+func (ctx ProgramContext) exit(code int64) {
+	closePrinter()
+	os.Exit(int(code))
+}
+
 var (
-	sqrt  = math.Sqrt
-	pow   = math.Pow
 	abs   = math.Abs
 	cos   = math.Cos
 	round = math.Round
 	sin   = math.Sin
 	tan   = math.Tan
+	floor = math.Floor
+	ceil  = math.Ceil
+	trunc = math.Trunc
+	atan  = math.Atan
+	atan2 = math.Atan2
 )
 
+func sqrt(x float64) float64 {
+	if x < 0 {
+		panic(fmt.Sprintf("sqrt: argument must not be negative, got %s", toString(x)))
+	}
+	return math.Sqrt(x)
+}
+
+func pow(x float64, y float64) float64 {
+	return checkFinite("pow", math.Pow(x, y), x, y)
+}
+
+func exp(x float64) float64 {
+	return checkFinite("exp", math.Exp(x), x)
+}
+
+func log(x float64) float64 {
+	if x <= 0 {
+		panic(fmt.Sprintf("log: argument must be positive, got %s", toString(x)))
+	}
+	return math.Log(x)
+}
+
+func log10(x float64) float64 {
+	if x <= 0 {
+		panic(fmt.Sprintf("log10: argument must be positive, got %s", toString(x)))
+	}
+	return math.Log10(x)
+}
+
+func acos(x float64) float64 {
+	if x < -1 || x > 1 {
+		panic(fmt.Sprintf("acos: argument must be between -1 and 1, got %s", toString(x)))
+	}
+	return math.Acos(x)
+}
+
+func asin(x float64) float64 {
+	if x < -1 || x > 1 {
+		panic(fmt.Sprintf("asin: argument must be between -1 and 1, got %s", toString(x)))
+	}
+	return math.Asin(x)
+}
+
+// checkFinite reports a NaN or infinite result from finite arguments as an error.
+func checkFinite(name string, ret float64, args ...float64) float64 {
+	if !math.IsNaN(ret) && !math.IsInf(ret, 0) {
+		return ret
+	}
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		if math.IsNaN(arg) || math.IsInf(arg, 0) {
+			return ret // garbage in, garbage out
+		}
+		strs[i] = toString(arg)
+	}
+	what := "is not a real number"
+	if math.IsInf(ret, 0) {
+		what = "is out of range"
+	}
+	panic(fmt.Sprintf("%s: result of %s(%s) %s", name, name, strings.Join(strs, ", "), what))
+}
+
+func absInteger(x int64) int64 {
+	if x == math.MinInt64 {
+		panic(fmt.Sprintf("abs: result of abs(%d) is out of range", x))
+	}
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInteger(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxReal(a float64, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInteger(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minReal(a float64, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 func toInteger(x float64) int64 {
 	return int64(x)
 }
@@ -59,8 +189,8 @@ func toString(arg any) string {
 		panic("should not get")
 	case string:
 		return x
-	case byte:
-		return string([]byte{x})
+	case rune:
+		return string(x)
 	case []any:
 		var sb strings.Builder
 		sb.WriteByte('[')
@@ -88,33 +218,281 @@ func currencyFormat(amount float64) string {
 	pennies := byte(cents % 100)
 
 	sb.WriteByte('$')
-	if dollars == 0 {
-		sb.WriteByte('0')
+	sb.WriteString(groupThousands(strconv.FormatInt(dollars, 10)))
+	sb.WriteByte('.')
+	sb.WriteByte('0' + pennies/10)
+	sb.WriteByte('0' + pennies%10)
+	return sb.String()
+}
+
+func formatInteger(x int64) string {
+	str := strconv.FormatInt(x, 10)
+	if x < 0 {
+		return "-" + groupThousands(str[1:])
+	}
+	return groupThousands(str)
+}
+
+func formatNumber(x float64, decimals int64) string {
+	if decimals < 0 {
+		panic(fmt.Sprintf("formatNumber: decimals must not be negative, got %d", decimals))
+	}
+	if decimals > maxDecimals {
+		panic(fmt.Sprintf("formatNumber: decimals must be at most %d, got %d", maxDecimals, decimals))
+	}
+	str := formatFixed(x, int(decimals))
+	if strings.Trim(str, "-0.") == "" {
+		str = strings.TrimPrefix(str, "-") // no negative zero
+	}
+	return groupNumber(str)
+}
+
+// maxDecimals limits the decimals formatNumber and format will produce.
+const maxDecimals = 20
+
+// formatFixed formats x with the given number of decimals, rounding half away from zero
+// like round and currencyFormat do, rather than half to even like strconv. It rounds the shortest
+// decimal that reads back as x, as Display shows it, so 1.005 rounds up to 1.01 even though the
+// nearest binary value is slightly less.
+func formatFixed(x float64, decimals int) string {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return strconv.FormatFloat(x, 'f', decimals, 64)
+	}
+	intPart, frac, _ := strings.Cut(strconv.FormatFloat(math.Abs(x), 'f', -1, 64), ".")
+	if len(frac) <= decimals {
+		frac += strings.Repeat("0", decimals-len(frac))
 	} else {
-		str := strconv.FormatInt(dollars, 10)
-		first := true
-		for str != "" {
-			if first {
-				first = false
+		roundUp := frac[decimals] >= '5'
+		frac = frac[:decimals]
+		if roundUp {
+			digits := []byte(intPart + frac)
+			i := len(digits) - 1
+			for ; i >= 0 && digits[i] == '9'; i-- {
+				digits[i] = '0'
+			}
+			if i < 0 {
+				digits = append([]byte{'1'}, digits...)
 			} else {
-				sb.WriteByte(',')
+				digits[i]++
 			}
-			count := len(str) % 3
-			if count == 0 {
-				count = 3
+			intPart, frac = string(digits[:len(digits)-decimals]), string(digits[len(digits)-decimals:])
+		}
+	}
+	str := intPart
+	if decimals > 0 {
+		str += "." + frac
+	}
+	if math.Signbit(x) {
+		str = "-" + str
+	}
+	return str
+}
+
+// groupNumber inserts thousands separators into a formatted decimal number.
+func groupNumber(str string) string {
+	sign := ""
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		sign, str = str[:1], str[1:]
+	}
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i:]
+	}
+	for _, c := range []byte(intPart) {
+		if c < '0' || c > '9' {
+			return sign + str // NaN, Inf
+		}
+	}
+	return sign + groupThousands(intPart) + fracPart
+}
+
+// groupThousands inserts a comma between every group of three digits.
+func groupThousands(digits string) string {
+	var sb strings.Builder
+	for first := true; digits != ""; first = false {
+		if !first {
+			sb.WriteByte(',')
+		}
+		count := len(digits) % 3
+		if count == 0 {
+			count = 3
+		}
+		sb.WriteString(digits[:count])
+		digits = digits[count:]
+	}
+	return sb.String()
+}
+
+func padLeft(s string, width int64) string {
+	return strings.Repeat(" ", padCount("padLeft", s, width)) + s
+}
+
+func padRight(s string, width int64) string {
+	return s + strings.Repeat(" ", padCount("padRight", s, width))
+}
+
+func padCount(name string, s string, width int64) int {
+	if width < 0 {
+		panic(fmt.Sprintf("%s: width must not be negative, got %d", name, width))
+	}
+	return max(0, int(width)-utf8.RuneCountInString(s))
+}
+
+// format is a printf-like formatter supporting these directives:
+//
+//	%d Integer
+//	%f, %e, %g Real (or Integer)
+//	%s any value
+//	%c Character
+//	%% a literal percent sign
+//
+// Directives accept the flags '-' (left justify), '0' (zero pad), '+' and ' ' (sign),
+// ',' (thousands separators), a width, and a precision.
+func format(f string, args ...any) string {
+	var sb strings.Builder
+	argc := 0
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			sb.WriteByte(f[i])
+			continue
+		}
+		start := i
+		for i++; i < len(f) && strings.IndexByte("-+ 0,", f[i]) >= 0; i++ {
+		}
+		flags := f[start+1 : i]
+		var width, prec int
+		width, i = scanDigits(f, i)
+		prec = -1
+		if i < len(f) && f[i] == '.' {
+			prec, i = scanDigits(f, i+1)
+		}
+		if i >= len(f) {
+			panic(fmt.Sprintf("format: incomplete directive %q", f[start:]))
+		}
+		spec, verb := f[start:i+1], f[i]
+		if verb == '%' {
+			if spec != "%%" {
+				panic(fmt.Sprintf("format: invalid directive %q", spec))
 			}
-			sb.WriteString(str[:count])
-			str = str[count:]
+			sb.WriteByte('%')
+			continue
+		}
+		if argc >= len(args) {
+			panic(fmt.Sprintf("format: missing argument for %s", spec))
 		}
+		sb.WriteString(formatArg(spec, flags, width, prec, verb, args[argc]))
+		argc++
+	}
+	if argc < len(args) {
+		panic(fmt.Sprintf("format: got %d arguments, but only %d used", len(args), argc))
 	}
-	sb.WriteByte('.')
-	sb.WriteByte('0' + pennies/10)
-	sb.WriteByte('0' + pennies%10)
 	return sb.String()
 }
 
+func scanDigits(f string, i int) (int, int) {
+	ret := 0
+	for ; i < len(f) && f[i] >= '0' && f[i] <= '9'; i++ {
+		ret = ret*10 + int(f[i]-'0')
+	}
+	return ret, i
+}
+
+func formatArg(spec string, flags string, width int, prec int, verb byte, arg any) string {
+	typeError := func(want string) {
+		panic(fmt.Sprintf("format: %s expects %s, got %s", spec, want, typeName(arg)))
+	}
+	isNumber := true
+	var body string
+	switch verb {
+	case 'd':
+		x, ok := arg.(int64)
+		if !ok {
+			typeError("an Integer")
+		}
+		body = strconv.FormatInt(x, 10)
+	case 'f', 'e', 'g':
+		var x float64
+		switch arg := arg.(type) {
+		case float64:
+			x = arg
+		case int64:
+			x = float64(arg)
+		default:
+			typeError("a Real")
+		}
+		if prec < 0 {
+			prec = 6
+		}
+		if prec > maxDecimals {
+			panic(fmt.Sprintf("format: precision of %s must be at most %d", spec, maxDecimals))
+		}
+		if verb == 'f' {
+			body = formatFixed(x, prec)
+		} else {
+			body = strconv.FormatFloat(x, verb, prec, 64)
+		}
+	case 's':
+		isNumber = false
+		body = toString(arg)
+		if runes := []rune(body); prec >= 0 && prec < len(runes) {
+			body = string(runes[:prec])
+		}
+	case 'c':
+		isNumber = false
+		x, ok := arg.(rune)
+		if !ok {
+			typeError("a Character")
+		}
+		body = string(x)
+	default:
+		panic(fmt.Sprintf("format: unknown directive %q", spec))
+	}
+
+	sign := ""
+	if isNumber {
+		if body[0] == '-' {
+			sign, body = "-", body[1:]
+		} else if strings.IndexByte(flags, '+') >= 0 {
+			sign = "+"
+		} else if strings.IndexByte(flags, ' ') >= 0 {
+			sign = " "
+		}
+		if strings.IndexByte(flags, ',') >= 0 && verb != 'e' && verb != 'g' {
+			body = groupNumber(body)
+		}
+	}
+
+	pad := max(0, width-len(sign)-utf8.RuneCountInString(body))
+	switch {
+	case strings.IndexByte(flags, '-') >= 0:
+		return sign + body + strings.Repeat(" ", pad)
+	case isNumber && strings.IndexByte(flags, '0') >= 0:
+		return sign + strings.Repeat("0", pad) + body
+	default:
+		return strings.Repeat(" ", pad) + sign + body
+	}
+}
+
+func typeName(arg any) string {
+	switch arg.(type) {
+	case int64:
+		return "Integer"
+	case float64:
+		return "Real"
+	case string:
+		return "String"
+	case rune:
+		return "Character"
+	case bool:
+		return "Boolean"
+	default:
+		return fmt.Sprintf("%T", arg)
+	}
+}
+
+// length returns the number of characters in s, which may be fewer than its UTF-8 bytes.
 func length(s string) int64 {
-	return int64(len(s))
+	return int64(utf8.RuneCountInString(s))
 }
 
 func appendString(a, b string) string {
@@ -127,24 +505,97 @@ var (
 )
 
 func substring(s string, start int64, end int64) string {
-	return s[start : end+1]
+	runes := []rune(s)
+	checkRange("substring", runes, start, end)
+	return string(runes[start : end+1])
 }
 
 func insertString(s string, pos int64, add string) string {
-	lhs := s[:pos]
-	rhs := s[pos:]
+	runes := []rune(s)
+	if pos < 0 || pos > int64(len(runes)) {
+		panic(Errorf(IndexOutOfRange, "insert: position %d out of range for String of length %d", pos, len(runes)))
+	}
+	lhs := string(runes[:pos])
+	rhs := string(runes[pos:])
 	return lhs + add + rhs
 }
 
 func deleteString(s string, start int64, end int64) string {
-	if end+1 < start {
-		panic("delete: invalid range start(%d) should be less than or equal to end (%d)")
-	}
-	lhs := s[:start]
-	rhs := s[end+1:]
+	runes := []rune(s)
+	checkRange("delete", runes, start, end)
+	lhs := string(runes[:start])
+	rhs := string(runes[end+1:])
 	return lhs + rhs
 }
 
+// checkRange validates an inclusive [start, end] range; an empty range has end == start-1.
+func checkRange(name string, runes []rune, start int64, end int64) {
+	if start < 0 || start > int64(len(runes)) {
+		panic(Errorf(IndexOutOfRange, "%s: start index %d out of range for String of length %d", name, start, len(runes)))
+	}
+	if end < start-1 {
+		panic(Errorf(IndexOutOfRange, "%s: end index %d is before start index %d", name, end, start))
+	}
+	if end >= int64(len(runes)) {
+		panic(Errorf(IndexOutOfRange, "%s: end index %d out of range for String of length %d", name, end, len(runes)))
+	}
+}
+
+func indexOf(s string, sub string) int64 {
+	return runeIndex(s, strings.Index(s, sub))
+}
+
+func lastIndexOf(s string, sub string) int64 {
+	return runeIndex(s, strings.LastIndex(s, sub))
+}
+
+// runeIndex converts a byte offset into s to a character index, passing through -1 for not found.
+func runeIndex(s string, i int) int64 {
+	if i < 0 {
+		return -1
+	}
+	return int64(utf8.RuneCountInString(s[:i]))
+}
+
+var (
+	startsWith = strings.HasPrefix
+	endsWith   = strings.HasSuffix
+	trim       = strings.TrimSpace
+)
+
+func replace(s string, from string, to string) string {
+	return strings.ReplaceAll(s, from, to)
+}
+
+func repeat(s string, count int64) string {
+	if count < 0 {
+		panic(fmt.Sprintf("repeat: count must not be negative, got %d", count))
+	}
+	return strings.Repeat(s, int(count))
+}
+
+func split(s string, sep string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, sep)
+}
+
+func join(arr []string, sep string) string {
+	return strings.Join(arr, sep)
+}
+
+func ord(c rune) int64 {
+	return int64(c)
+}
+
+func chr(code int64) rune {
+	if code < 0 || code > unicode.MaxRune || !utf8.ValidRune(rune(code)) {
+		panic(fmt.Sprintf("chr: %d is not a valid character code", code))
+	}
+	return rune(code)
+}
+
 var contains = strings.Contains
 
 func stringToInteger(s string) int64 {
@@ -173,35 +624,181 @@ func isReal(s string) bool {
 	return err == nil
 }
 
-func isDigit(c byte) bool {
-	return unicode.IsDigit(rune(c))
+func isDigit(c rune) bool {
+	return unicode.IsDigit(c)
 }
 
-func isLetter(c byte) bool {
-	return unicode.IsLetter(rune(c))
+func isLetter(c rune) bool {
+	return unicode.IsLetter(c)
 }
 
-func isLower(c byte) bool {
-	return unicode.IsLower(rune(c))
+func isLower(c rune) bool {
+	return unicode.IsLower(c)
 }
 
-func isUpper(c byte) bool {
-	return unicode.IsUpper(rune(c))
+func isUpper(c rune) bool {
+	return unicode.IsUpper(c)
 }
 
-func isWhitespace(c byte) bool {
-	return unicode.IsSpace(rune(c))
+func isWhitespace(c rune) bool {
+	return unicode.IsSpace(c)
 }
 
-func stringWithCharUpdate(str string, idx int64, c byte) string {
-	buf := []byte(str)
-	buf[idx] = c
-	return string(buf)
+// size returns the number of elements in any array.
+func size(arr any) int64 {
+	return int64(reflect.ValueOf(arr).Len())
+}
+
+var arrayLength = size
+
+// checkArraySize panics unless src has the same size as dst in every dimension; it guards whole-array
+// Set when the sizes aren't known at compile time.
+func checkArraySize(dst any, src any) {
+	dstDims, srcDims := arrayDims(reflect.ValueOf(dst)), arrayDims(reflect.ValueOf(src))
+	if !slices.Equal(dstDims, srcDims) {
+		panic(Errorf(ArraySizeMismatch, "array size mismatch: %s not assignable to %s", formatArrayDims(srcDims), formatArrayDims(dstDims)))
+	}
+}
+
+// assignArray is the gogen version of a checked whole-array Set.
+func assignArray[T any](dst *[]T, src []T) {
+	checkArraySize(*dst, src)
+	*dst = src
+}
+
+// arrayDims returns the size of each dimension of an array; arrays are never ragged.
+func arrayDims(arr reflect.Value) []int {
+	var dims []int
+	for arr.Kind() == reflect.Slice {
+		dims = append(dims, arr.Len())
+		if arr.Len() == 0 {
+			break
+		}
+		arr = arr.Index(0)
+		if arr.Kind() == reflect.Interface {
+			arr = arr.Elem()
+		}
+	}
+	return dims
+}
+
+func formatArrayDims(dims []int) string {
+	var sb strings.Builder
+	for _, d := range dims {
+		_, _ = fmt.Fprintf(&sb, "[%d]", d)
+	}
+	return sb.String()
+}
+
+func sumIntegerArray(arr []int64) int64 {
+	var ret int64
+	for _, v := range arr {
+		ret += v
+	}
+	return ret
+}
+
+func sumRealArray(arr []float64) float64 {
+	var ret float64
+	for _, v := range arr {
+		ret += v
+	}
+	return ret
+}
+
+func maxIntegerArray(arr []int64) int64 {
+	checkNotEmpty("max", len(arr))
+	ret := arr[0]
+	for _, v := range arr[1:] {
+		if v > ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+func maxRealArray(arr []float64) float64 {
+	checkNotEmpty("max", len(arr))
+	ret := arr[0]
+	for _, v := range arr[1:] {
+		if v > ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+func minIntegerArray(arr []int64) int64 {
+	checkNotEmpty("min", len(arr))
+	ret := arr[0]
+	for _, v := range arr[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+func minRealArray(arr []float64) float64 {
+	checkNotEmpty("min", len(arr))
+	ret := arr[0]
+	for _, v := range arr[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+func averageIntegerArray(arr []int64) float64 {
+	checkNotEmpty("average", len(arr))
+	return float64(sumIntegerArray(arr)) / float64(len(arr))
+}
+
+func averageRealArray(arr []float64) float64 {
+	checkNotEmpty("average", len(arr))
+	return sumRealArray(arr) / float64(len(arr))
+}
+
+func checkNotEmpty(name string, n int) {
+	if n == 0 {
+		panic(fmt.Sprintf("%s: array must not be empty", name))
+	}
+}
+
+// CharAt returns the character at the given index of str.
+func CharAt(str string, idx int64) rune {
+	// walk the String rather than converting all of it, so indexing doesn't allocate
+	n := 0
+	for _, c := range str {
+		if int64(n) == idx {
+			return c
+		}
+		n++
+	}
+	panic(indexError("charAt", n, idx))
+}
+
+func stringWithCharUpdate(str string, idx int64, c rune) string {
+	runes := []rune(str)
+	checkIndex("setCharAt", len(runes), idx)
+	runes[idx] = c
+	return string(runes)
+}
+
+func checkIndex(name string, n int, idx int64) {
+	if idx < 0 || idx >= int64(n) {
+		panic(indexError(name, n, idx))
+	}
+}
+
+func indexError(name string, n int, idx int64) RuntimeError {
+	return Errorf(IndexOutOfRange, "%s: index %d out of range for String of length %d", name, idx, n)
 }
 
 // BELOW: Used only by the gogen runtime.
 
-func stringWithCharUpdateRef(s *string, idx int64, c byte) {
+func stringWithCharUpdateRef(s *string, idx int64, c rune) {
 	*s = stringWithCharUpdate(*s, idx, c)
 }
 
@@ -220,7 +817,14 @@ var (
 
 	random = randCtx.random
 
+	progCtx = ProgramContext{Args: os.Args[1:]}
+
+	argumentCount = progCtx.argumentCount
+	argument      = progCtx.argument
+	exit          = progCtx.exit
+
 	_ = stringWithCharUpdateRef
 	_ = deleteStringRef
 	_ = insertStringRef
+	_ = assignArray[int64]
 )
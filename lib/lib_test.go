@@ -1,6 +1,9 @@
 package lib
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -38,6 +41,8 @@ func TestSubstring(t *testing.T) {
 		{"abcd", 1, 2, "bc"},
 		{"abcd", 1, 3, "bcd"},
 		{"abcd", 2, 3, "cd"},
+		{"José", 2, 3, "sé"},
+		{"Zoë!", 2, 2, "ë"},
 	} {
 		got := substring(tc.in, tc.start, tc.end)
 		if got != tc.want {
@@ -60,6 +65,8 @@ func TestInsert(t *testing.T) {
 		{"abcd", "!", 2, "ab!cd"},
 		{"abcd", "!!", 3, "abc!!d"},
 		{"abcd", "!!!", 4, "abcd!!!"},
+		{"Zoë", "!", 3, "Zoë!"},
+		{"日本", "の", 1, "日の本"},
 	} {
 		got := insertString(tc.in, tc.pos, tc.add)
 		if got != tc.want {
@@ -81,6 +88,8 @@ func TestDelete(t *testing.T) {
 		{"abcd", 1, 2, "ad"},
 		{"abcd", 1, 3, "a"},
 		{"abcd", 2, 3, "ab"},
+		{"José", 3, 3, "Jos"},
+		{"Zoë!", 2, 2, "Zo!"},
 	} {
 		got := deleteString(tc.in, tc.start, tc.end)
 		if got != tc.want {
@@ -88,3 +97,200 @@ func TestDelete(t *testing.T) {
 		}
 	}
 }
+
+func TestMathDomainErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		fn   func()
+		want string
+	}{
+		{"sqrt", func() { sqrt(-1) }, "sqrt: argument must not be negative, got -1"},
+		{"log", func() { log(0) }, "log: argument must be positive, got 0"},
+		{"log10", func() { log10(-2.5) }, "log10: argument must be positive, got -2.5"},
+		{"acos", func() { acos(2) }, "acos: argument must be between -1 and 1, got 2"},
+		{"asin", func() { asin(-1.5) }, "asin: argument must be between -1 and 1, got -1.5"},
+		{"pow", func() { pow(-8, 0.5) }, "pow: result of pow(-8, 0.5) is not a real number"},
+		{"pow", func() { pow(0, -1) }, "pow: result of pow(0, -1) is out of range"},
+		{"exp", func() { exp(1000) }, "exp: result of exp(1000) is out of range"},
+		{"abs", func() { absInteger(math.MinInt64) }, "abs: result of abs(-9223372036854775808) is out of range"},
+	} {
+		got := func() (ret any) {
+			defer func() { ret = recover() }()
+			tc.fn()
+			return nil
+		}()
+		if got != tc.want {
+			t.Errorf("%s: got %v, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestStringIndexErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		fn   func()
+		want string
+	}{
+		{"substring", func() { substring("abc", -1, 1) }, "substring: start index -1 out of range for String of length 3"},
+		{"substring", func() { substring("abc", 1, 3) }, "substring: end index 3 out of range for String of length 3"},
+		{"substring", func() { substring("abc", 2, 0) }, "substring: end index 0 is before start index 2"},
+		{"insert", func() { insertString("abc", 4, "!") }, "insert: position 4 out of range for String of length 3"},
+		{"delete", func() { deleteString("abc", 4, 4) }, "delete: start index 4 out of range for String of length 3"},
+		{"delete", func() { deleteString("abc", 2, 0) }, "delete: end index 0 is before start index 2"},
+		{"repeat", func() { repeat("abc", -2) }, "repeat: count must not be negative, got -2"},
+		{"chr", func() { chr(-1) }, "chr: -1 is not a valid character code"},
+		{"chr", func() { chr(0xD800) }, "chr: 55296 is not a valid character code"},
+		{"set", func() { stringWithCharUpdate("abc", 3, 'x') }, "setCharAt: index 3 out of range for String of length 3"},
+		{"set", func() { stringWithCharUpdate("Zoë", 3, 'x') }, "setCharAt: index 3 out of range for String of length 3"},
+		{"index", func() { CharAt("José", 4) }, "charAt: index 4 out of range for String of length 4"},
+		{"index", func() { CharAt("José", -1) }, "charAt: index -1 out of range for String of length 4"},
+	} {
+		got := func() (ret any) {
+			defer func() { ret = recover() }()
+			tc.fn()
+			return nil
+		}()
+		if fmt.Sprint(got) != tc.want {
+			t.Errorf("%s: got %v, want %s", tc.name, got, tc.want)
+		}
+		if re, ok := got.(RuntimeError); strings.Contains(tc.want, "index") && (!ok || re.Kind != IndexOutOfRange) {
+			t.Errorf("%s: got %#v, want an IndexOutOfRange RuntimeError", tc.name, got)
+		}
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+	assertEqual(t, int64(4), length("José"))
+	assertEqual(t, 'é', CharAt("José", 3))
+	assertEqual(t, 'Z', CharAt("Zoë", 0))
+	assertEqual(t, 's', CharAt("José", 2))
+	assertEqual(t, "Zoë", stringWithCharUpdate("Zoe", 2, 'ë'))
+	assertEqual(t, int64(3), indexOf("Zoë Zoë", " "))
+	assertEqual(t, int64(6), lastIndexOf("Zoë Zoë", "ë"))
+	assertEqual(t, int64(-1), indexOf("Zoë", "e"))
+	assertEqual(t, int64(233), ord('é'))
+	assertEqual(t, '日', chr(0x65E5))
+	assertEqual(t, true, isLetter('ë'))
+	assertEqual(t, true, isUpper('É'))
+	assertEqual(t, "    Zoë", padLeft("Zoë", 7))
+}
+
+func TestFormatNumber(t *testing.T) {
+	for _, tc := range []struct {
+		in       float64
+		decimals int64
+		want     string
+	}{
+		{0, 2, "0.00"},
+		{-0.4, 0, "0"},
+		{999.999, 2, "1,000.00"},
+		{1234567.891, 1, "1,234,567.9"},
+		{-9876.54, 3, "-9,876.540"},
+		{2.5, 0, "3"},
+		{-2.5, 0, "-3"},
+		{0.125, 2, "0.13"},
+		{-0.5, 0, "-1"},
+		{1.005, 2, "1.01"},
+		{2.675, 2, "2.68"},
+		{-1.005, 2, "-1.01"},
+		{9.995, 2, "10.00"},
+		{0.1, 20, "0.10000000000000000000"},
+	} {
+		got := formatNumber(tc.in, tc.decimals)
+		if got != tc.want {
+			t.Errorf("formatNumber(%f, %d) = %s, want %s", tc.in, tc.decimals, got, tc.want)
+		}
+	}
+	if got := formatInteger(-1234567); got != "-1,234,567" {
+		t.Errorf("formatInteger(-1234567) = %s", got)
+	}
+
+	const want = "formatNumber: decimals must be at most 20, got 1000000000"
+	got := func() (ret any) {
+		defer func() { ret = recover() }()
+		formatNumber(1.0, 1000000000)
+		return nil
+	}()
+	if got != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		f    string
+		args []any
+		want string
+	}{
+		{"%d|%5d|%-5d|%05d|%+d|% d", []any{int64(1), int64(2), int64(3), int64(-4), int64(5), int64(6)}, "1|    2|3    |-0004|+5| 6"},
+		{"%.2f|%8.3f|%,.0f|%f", []any{1.005, 3.14159, 1234567.5, int64(2)}, "1.01|   3.142|1,234,568|2.000000"},
+		{"%s %s %s %c", []any{"x", true, int64(7), 'c'}, "x True 7 c"},
+		{"%,d%%", []any{int64(1000)}, "1,000%"},
+		{"%.0f|%.1f|%.0f", []any{2.5, 0.25, -0.5}, "3|0.3|-1"},
+		{"%-6s|%.3s|%3c", []any{"José", "Zoë!", 'é'}, "José  |Zoë|  é"},
+	} {
+		got := format(tc.f, tc.args...)
+		if got != tc.want {
+			t.Errorf("format(%q) = %q, want %q", tc.f, got, tc.want)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	for _, tc := range []struct {
+		f    string
+		args []any
+		want string
+	}{
+		{"%d", []any{1.5}, "format: %d expects an Integer, got Real"},
+		{"%c", []any{"s"}, "format: %c expects a Character, got String"},
+		{"%d %d", []any{int64(1)}, "format: missing argument for %d"},
+		{"%d", []any{int64(1), int64(2)}, "format: got 2 arguments, but only 1 used"},
+		{"%q", []any{int64(1)}, `format: unknown directive "%q"`},
+		{"50%", nil, `format: incomplete directive "%"`},
+		{"%.21f", []any{1.0}, "format: precision of %.21f must be at most 20"},
+	} {
+		got := func() (ret any) {
+			defer func() { ret = recover() }()
+			format(tc.f, tc.args...)
+			return nil
+		}()
+		if got != tc.want {
+			t.Errorf("format(%q): got %v, want %s", tc.f, got, tc.want)
+		}
+	}
+}
+
+func TestCheckArraySize(t *testing.T) {
+	for _, tc := range []struct {
+		dst, src any
+		want     string
+	}{
+		{[]int64{1, 2}, []int64{3, 4}, ""},
+		{[]int64{1, 2}, []int64{3}, "array size mismatch: [1] not assignable to [2]"},
+		{[][]string{{"a"}, {"b"}}, [][]string{{"c", "d"}, {"e", "f"}}, "array size mismatch: [2][2] not assignable to [2][1]"},
+		// arrays in the interpreter
+		{[]any{[]any{int64(1)}, []any{int64(2)}}, []any{[]any{int64(3)}, []any{int64(4)}}, ""},
+		{[]any{[]any{int64(1)}}, []any{[]any{int64(3)}, []any{int64(4)}}, "array size mismatch: [2][1] not assignable to [1][1]"},
+	} {
+		got := func() (ret any) {
+			defer func() { ret = recover() }()
+			checkArraySize(tc.dst, tc.src)
+			return nil
+		}()
+		if got == nil {
+			got = ""
+		} else if re, ok := got.(RuntimeError); !ok || re.Kind != ArraySizeMismatch {
+			t.Errorf("checkArraySize(%v, %v): got %#v, want an array size mismatch", tc.dst, tc.src, got)
+		} else {
+			got = re.Msg
+		}
+		if got != tc.want {
+			t.Errorf("checkArraySize(%v, %v): got %v, want %s", tc.dst, tc.src, got, tc.want)
+		}
+	}
+
+	dst := []int64{1, 2}
+	assignArray(&dst, []int64{3, 4})
+	assertEqual(t, int64(4), dst[1])
+}
@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clock provides the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+var SystemClock Clock = systemClock{}
+
+// FakeTime is the starting time of a FakeClock.
+var FakeTime = time.Date(2024, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+// FakeClock is a deterministic clock for tests. Each reading advances the clock by one millisecond,
+// so that programs waiting for time to elapse still terminate.
+type FakeClock struct {
+	T time.Time
+}
+
+func NewFakeClock() *FakeClock {
+	return &FakeClock{T: FakeTime}
+}
+
+func (c *FakeClock) Now() time.Time {
+	ret := c.T
+	c.T = c.T.Add(time.Millisecond)
+	return ret
+}
+
+type TimeContext struct {
+	Clock Clock
+	Start time.Time
+}
+
+func NewTimeContext(clock Clock) *TimeContext {
+	return &TimeContext{Clock: clock, Start: clock.Now()}
+}
+
+func (ctx *TimeContext) today() int64 {
+	return fromTime(ctx.Clock.Now())
+}
+
+func (ctx *TimeContext) currentYear() int64 {
+	return int64(ctx.Clock.Now().Year())
+}
+
+func (ctx *TimeContext) currentMonth() int64 {
+	return int64(ctx.Clock.Now().Month())
+}
+
+func (ctx *TimeContext) currentDay() int64 {
+	return int64(ctx.Clock.Now().Day())
+}
+
+func (ctx *TimeContext) currentHour() int64 {
+	return int64(ctx.Clock.Now().Hour())
+}
+
+func (ctx *TimeContext) currentMinute() int64 {
+	return int64(ctx.Clock.Now().Minute())
+}
+
+func (ctx *TimeContext) currentSecond() int64 {
+	return int64(ctx.Clock.Now().Second())
+}
+
+func (ctx *TimeContext) elapsedMilliseconds() int64 {
+	return ctx.Clock.Now().Sub(ctx.Start).Milliseconds()
+}
+
+// Dates are represented as Integer values of the form YYYYMMDD, e.g. 20240115.
+// This makes them readable, and comparable with the ordinary relational operators.
+
+func makeDate(year int64, month int64, day int64) int64 {
+	t := time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC)
+	if int64(t.Year()) != year || int64(t.Month()) != month || int64(t.Day()) != day {
+		panic(fmt.Sprintf("makeDate: invalid date %04d-%02d-%02d", year, month, day))
+	}
+	return fromTime(t)
+}
+
+func yearOf(date int64) int64 {
+	return int64(toTime("yearOf", date).Year())
+}
+
+func monthOf(date int64) int64 {
+	return int64(toTime("monthOf", date).Month())
+}
+
+func dayOf(date int64) int64 {
+	return int64(toTime("dayOf", date).Day())
+}
+
+func dayOfWeek(date int64) string {
+	return toTime("dayOfWeek", date).Weekday().String()
+}
+
+func addDays(date int64, days int64) int64 {
+	return fromTime(toTime("addDays", date).AddDate(0, 0, int(days)))
+}
+
+func daysBetween(from int64, to int64) int64 {
+	d := toTime("daysBetween", to).Sub(toTime("daysBetween", from))
+	return int64(d / (24 * time.Hour))
+}
+
+// formatDate formats a date using the pattern elements YYYY, MMMM (month name), MMM (short month name),
+// MM, DDDD (day of week), DD; anything else is copied literally.
+func formatDate(date int64, pattern string) string {
+	t := toTime("formatDate", date)
+	r := strings.NewReplacer(
+		"YYYY", fmt.Sprintf("%04d", t.Year()),
+		"MMMM", t.Month().String(),
+		"MMM", t.Month().String()[:3],
+		"MM", fmt.Sprintf("%02d", t.Month()),
+		"DDDD", t.Weekday().String(),
+		"DD", fmt.Sprintf("%02d", t.Day()),
+	)
+	return r.Replace(pattern)
+}
+
+func fromTime(t time.Time) int64 {
+	return int64(t.Year())*10000 + int64(t.Month())*100 + int64(t.Day())
+}
+
+func toTime(name string, date int64) time.Time {
+	year, month, day := date/10000, date/100%100, date%100
+	t := time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC)
+	if date < 0 || fromTime(t) != date {
+		panic(fmt.Sprintf("%s: invalid date %d, expected YYYYMMDD", name, date))
+	}
+	return t
+}
+
+// BELOW: Used only by the gogen runtime.
+
+var (
+	timeCtx = NewTimeContext(SystemClock)
+
+	today               = timeCtx.today
+	currentYear         = timeCtx.currentYear
+	currentMonth        = timeCtx.currentMonth
+	currentDay          = timeCtx.currentDay
+	currentHour         = timeCtx.currentHour
+	currentMinute       = timeCtx.currentMinute
+	currentSecond       = timeCtx.currentSecond
+	elapsedMilliseconds = timeCtx.elapsedMilliseconds
+)
@@ -0,0 +1,39 @@
+package lib
+
+import "testing"
+
+func TestDates(t *testing.T) {
+	assertEqual(t, int64(20240229), makeDate(2024, 2, 29))
+	assertEqual(t, int64(20250101), addDays(20241231, 1))
+	assertEqual(t, int64(20240301), addDays(20240229, 1))
+	assertEqual(t, int64(-366), daysBetween(20250101, 20240101))
+	assertEqual(t, "Thursday", dayOfWeek(20240229))
+	assertEqual(t, "Feb 29, 2024 (02/29)", formatDate(20240229, "MMM DD, YYYY (MM/DD)"))
+}
+
+func TestInvalidDates(t *testing.T) {
+	for _, tc := range []struct {
+		fn   func()
+		want string
+	}{
+		{func() { makeDate(2023, 2, 29) }, "makeDate: invalid date 2023-02-29"},
+		{func() { yearOf(20241301) }, "yearOf: invalid date 20241301, expected YYYYMMDD"},
+		{func() { addDays(-1, 1) }, "addDays: invalid date -1, expected YYYYMMDD"},
+	} {
+		got := func() (ret any) {
+			defer func() { ret = recover() }()
+			tc.fn()
+			return nil
+		}()
+		if got != tc.want {
+			t.Errorf("got %v, want %s", got, tc.want)
+		}
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	tc := NewTimeContext(NewFakeClock())
+	assertEqual(t, int64(20240115), tc.today())
+	assertEqual(t, int64(9), tc.currentHour())
+	assertEqual(t, int64(3), tc.elapsedMilliseconds())
+}
@@ -23,6 +23,9 @@ func (p *Parser) parseClassBody(r lex.Result) *ast.ClassStmt {
 	var stmts []ast.Statement
 	for {
 		peek := p.SafePeek()
+		if peek.Token == lex.EOF {
+			panic(p.Errorf(peek, "expected End Class, got EOF"))
+		}
 		if peek.Token == lex.END {
 			p.parseTok(lex.END)
 			rEnd := p.parseTok(lex.CLASS)
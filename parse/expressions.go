@@ -6,6 +6,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 func (p *Parser) parseCommaExpressions(endTokens ...lex.Token) []ast.Expression {
@@ -178,10 +179,11 @@ func ParseLiteral(input string, si ast.SourceInfo, typ ast.PrimitiveType) *ast.L
 		return &ast.Literal{SourceInfo: si, Type: ast.String, Val: v}
 	case ast.Character:
 		v, err := strconv.Unquote(input)
-		if err != nil || len(v) > 1 {
+		r, size := utf8.DecodeRuneInString(v)
+		if err != nil || size == 0 || size != len(v) {
 			return nil
 		}
-		return &ast.Literal{SourceInfo: si, Type: ast.Character, Val: v[0]}
+		return &ast.Literal{SourceInfo: si, Type: ast.Character, Val: r}
 	case ast.Boolean:
 		// ToLower only to support dynamic eval; lexer would not suppport.
 		switch strings.ToLower(input) {
@@ -28,6 +28,12 @@ func (p *Parser) parseModuleStmt(r lex.Result) *ast.ModuleStmt {
 
 func (p *Parser) parseFunctionStmt(r lex.Result) *ast.FunctionStmt {
 	returnType := p.parseType()
+	baseType := returnType
+	for nDims := 1; p.hasTok(lex.LBRACKET); nDims++ {
+		p.parseTok(lex.LBRACKET)
+		p.parseTok(lex.RBRACKET)
+		returnType = p.makeArrayType(baseType, nDims, returnType)
+	}
 	rNext := p.parseTok(lex.IDENT)
 	name := rNext.Text
 
@@ -12,8 +12,15 @@ import (
 const maxErrors = 20
 
 func Parse(input string) (*ast.Program, []ast.Comment, []ast.Error) {
+	return ParseWithTypes(input, map[ast.TypeKey]ast.Type{})
+}
+
+// ParseWithTypes is like Parse, but interns class and array types in the given map, so that
+// separately parsed programs can share types; used by the REPL.
+func ParseWithTypes(input string, types map[ast.TypeKey]ast.Type) (*ast.Program, []ast.Comment, []ast.Error) {
 	l := lex.New(input)
 	p := New(l)
+	p.types = types
 	ret := p.parseGlobalBlock()
 	ret.Block.End = toSourceInfo(l.Lex()).End
 	errors := p.errors
@@ -29,6 +36,15 @@ func ParseExpr(input string) (ast.Expression, error) {
 	return p.safeParseExpression()
 }
 
+// ParseWholeExpr parses input as a single expression, failing if anything follows it.
+// Like ParseWithTypes, any class and array types are interned in types.
+func ParseWholeExpr(input string, types map[ast.TypeKey]ast.Type) (ast.Expression, error) {
+	l := lex.New(input)
+	p := New(l)
+	p.types = types
+	return p.safeParseWholeExpression()
+}
+
 func New(l *lex.Lexer) *Parser {
 	return &Parser{
 		lex:  l,
@@ -145,6 +161,27 @@ func (p *Parser) safeParseExpression() (_ ast.Expression, err error) {
 	return p.parseExpression(), nil
 }
 
+func (p *Parser) safeParseWholeExpression() (_ ast.Expression, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if pe, ok := e.(ast.Error); ok {
+				err = pe
+			} else {
+				panic(e)
+			}
+		}
+	}()
+
+	expr := p.parseExpression()
+	for p.hasTok(lex.EOL) {
+		p.Next()
+	}
+	if r := p.Peek(); r.Token != lex.EOF {
+		panic(p.Errorf(r, "unexpected %s after expression", r.Token))
+	}
+	return expr, nil
+}
+
 type EmptyStatement struct {
 	ast.Statement
 }
@@ -264,7 +301,7 @@ func (p *Parser) parseStatement(isGlobalBlock bool) ast.Statement {
 		case lex.UNTIL:
 			until = true
 		default:
-			panic(p.Errorf(r, "expected While or Until, got %s %q", r.Token, r.Text))
+			panic(p.Errorf(rEnd, "expected While or Until, got %s %q", rEnd.Token, rEnd.Text))
 		}
 		expr := p.parseExpression()
 		return &ast.DoStmt{SourceInfo: spanAst(r, expr), Block: block, Until: until, Expr: expr}
@@ -330,7 +367,25 @@ func (p *Parser) parseStatement(isGlobalBlock bool) ast.Statement {
 	case lex.OPEN:
 		file := p.parseExpression()
 		nameExpr := p.parseExpression()
-		return &ast.OpenStmt{SourceInfo: spanAst(r, nameExpr), File: file, Name: nameExpr}
+		si := spanAst(r, nameExpr)
+		format := ast.GaddisFormat
+		// As is only a keyword here, so existing programs can still use it as a name
+		if peek := p.Peek(); peek.Token == lex.IDENT && peek.Text == "As" {
+			p.Next()
+			rFormat := p.parseTok(lex.IDENT)
+			var ok bool
+			if format, ok = ast.ParseFileFormat(rFormat.Text); !ok {
+				panic(p.Errorf(rFormat, "unknown file format %s, expected CSV or Text", rFormat.Text))
+			}
+			si.End = toSourceInfo(rFormat).End
+		}
+		var recordSize ast.Expression
+		if p.hasTok(lex.COMMA) {
+			p.parseTok(lex.COMMA)
+			recordSize = p.parseExpression()
+			si = mergeSourceInfo(si, recordSize)
+		}
+		return &ast.OpenStmt{SourceInfo: si, File: file, Name: nameExpr, Format: format, RecordSize: recordSize}
 	case lex.CLOSE:
 		file := p.parseExpression()
 		return &ast.CloseStmt{SourceInfo: spanAst(r, file), File: file}
@@ -35,3 +35,25 @@ func TestParse(t *testing.T) {
 		_ = os.WriteFile(filepath.Join(root, "parse_test_fmt.gad"), []byte(out), 0666)
 	}
 }
+
+func TestDoLoopErrors(t *testing.T) {
+	// the error points at the token that should have been While or Until, not at the Do
+	const src = "Do\n  Display 1\n"
+	const want = "3:1 syntax error: expected While or Until, got EOF \"\""
+	_, _, errs := Parse(src)
+	if len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("got %v, want %q", errs, want)
+	}
+}
+
+func TestAsIsNotReserved(t *testing.T) {
+	// As is only special after the file name in Open
+	const src = "Declare Integer As = 3\nDeclare InputFile f\nOpen f \"data.csv\" As CSV\nDisplay As\n"
+	block, comments, errs := Parse(src)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if out := astprint.Print(block, comments); out != src {
+		t.Errorf("got %q, want %q", out, src)
+	}
+}
@@ -3,6 +3,7 @@ package parse
 import (
 	"github.com/dragonsinth/gaddis/ast"
 	"github.com/dragonsinth/gaddis/lex"
+	"unicode/utf8"
 )
 
 func mergeSourceInfo(a ast.HasSourceInfo, b ast.HasSourceInfo) ast.SourceInfo {
@@ -27,7 +28,7 @@ func toSourceInfo(r lex.Result) ast.SourceInfo {
 	start := toPos(r.Pos)
 	end := start
 	end.Pos += len(r.Text)
-	end.Column += len(r.Text)
+	end.Column += utf8.RuneCountInString(r.Text)
 	return ast.SourceInfo{
 		Start: start,
 		End:   end,
@@ -22,6 +22,8 @@ func (p *Parser) parseType() ast.Type {
 		return ast.OutputFile
 	case lex.INPUTFILE:
 		return ast.InputFile
+	case lex.RANDOMFILE:
+		return ast.RandomFile
 	case lex.IDENT:
 		// must be a class
 		return p.makeClassType(r.Text)
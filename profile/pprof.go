@@ -0,0 +1,180 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"slices"
+)
+
+// WritePprof writes the profile in the gzipped protobuf format read by "go tool pprof", with a
+// sample for each calling context and line. filename is the program's source file.
+//
+// See https://github.com/google/pprof/blob/main/proto/profile.proto for the format.
+func (pr *Profiler) WritePprof(w io.Writer, filename string) error {
+	b := &pprofBuilder{
+		strings:   map[string]int64{"": 0},
+		strList:   []string{""},
+		funcs:     map[*Func]uint64{},
+		locations: map[location]uint64{},
+	}
+	filenameId := b.str(filename)
+
+	var prof protoBuf
+	sampleType := (&protoBuf{}).int64(1, b.str("instructions")).int64(2, b.str("count"))
+	prof.message(1, sampleType)
+
+	// visit the calling context tree in a stable order
+	var visit func(n *node, stack []uint64)
+	visit = func(n *node, stack []uint64) {
+		lines := make([]int, 0, len(n.lines))
+		for line := range n.lines {
+			lines = append(lines, line)
+		}
+		slices.Sort(lines)
+		for _, line := range lines {
+			locs := append([]uint64{b.location(n.fn, line)}, stack...)
+			sample := (&protoBuf{}).packedUint64(1, locs).packedInt64(2, []int64{n.lines[line]})
+			prof.message(2, sample)
+		}
+
+		children := make([]*node, 0, len(n.children))
+		for _, c := range n.children {
+			children = append(children, c)
+		}
+		slices.SortFunc(children, func(a, b *node) int {
+			if a.callLine != b.callLine {
+				return a.callLine - b.callLine
+			}
+			return a.fn.Line - b.fn.Line
+		})
+		for _, c := range children {
+			// the caller's frame is at the line that made the call
+			visit(c, append([]uint64{b.location(n.fn, c.callLine)}, stack...))
+		}
+	}
+	for _, c := range pr.root.children {
+		visit(c, nil)
+	}
+
+	for _, loc := range b.locList {
+		line := (&protoBuf{}).uint64(1, b.funcs[loc.fn]).int64(2, int64(loc.line+1))
+		prof.message(4, (&protoBuf{}).uint64(1, b.locations[loc]).message(4, line))
+	}
+	for _, fn := range b.funcList {
+		name := b.str(fn.Name)
+		prof.message(5, (&protoBuf{}).
+			uint64(1, b.funcs[fn]).
+			int64(2, name).
+			int64(3, name).
+			int64(4, filenameId).
+			int64(5, int64(fn.Line+1)))
+	}
+	for _, s := range b.strList {
+		prof.string(6, s)
+	}
+	prof.message(11, sampleType) // period type
+	prof.int64(12, 1)            // period
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(prof.buf); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type location struct {
+	fn   *Func
+	line int
+}
+
+type pprofBuilder struct {
+	strings   map[string]int64
+	strList   []string
+	funcs     map[*Func]uint64
+	funcList  []*Func
+	locations map[location]uint64
+	locList   []location
+}
+
+func (b *pprofBuilder) str(s string) int64 {
+	if id, ok := b.strings[s]; ok {
+		return id
+	}
+	id := int64(len(b.strList))
+	b.strings[s] = id
+	b.strList = append(b.strList, s)
+	return id
+}
+
+func (b *pprofBuilder) location(fn *Func, line int) uint64 {
+	if _, ok := b.funcs[fn]; !ok {
+		b.funcList = append(b.funcList, fn)
+		b.funcs[fn] = uint64(len(b.funcList)) // ids start at 1
+	}
+	loc := location{fn: fn, line: line}
+	if id, ok := b.locations[loc]; ok {
+		return id
+	}
+	b.locList = append(b.locList, loc)
+	id := uint64(len(b.locList))
+	b.locations[loc] = id
+	return id
+}
+
+// protoBuf encodes just enough protobuf for a pprof profile.
+type protoBuf struct {
+	buf []byte
+}
+
+func (pb *protoBuf) varint(x uint64) {
+	for x >= 0x80 {
+		pb.buf = append(pb.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	pb.buf = append(pb.buf, byte(x))
+}
+
+func (pb *protoBuf) tag(field int, wireType int) {
+	pb.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (pb *protoBuf) uint64(field int, x uint64) *protoBuf {
+	pb.tag(field, 0)
+	pb.varint(x)
+	return pb
+}
+
+func (pb *protoBuf) int64(field int, x int64) *protoBuf {
+	return pb.uint64(field, uint64(x))
+}
+
+func (pb *protoBuf) bytes(field int, b []byte) *protoBuf {
+	pb.tag(field, 2)
+	pb.varint(uint64(len(b)))
+	pb.buf = append(pb.buf, b...)
+	return pb
+}
+
+func (pb *protoBuf) string(field int, s string) *protoBuf {
+	return pb.bytes(field, []byte(s))
+}
+
+func (pb *protoBuf) message(field int, m *protoBuf) *protoBuf {
+	return pb.bytes(field, m.buf)
+}
+
+func (pb *protoBuf) packedUint64(field int, xs []uint64) *protoBuf {
+	var packed protoBuf
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	return pb.bytes(field, packed.buf)
+}
+
+func (pb *protoBuf) packedInt64(field int, xs []int64) *protoBuf {
+	var packed protoBuf
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	return pb.bytes(field, packed.buf)
+}
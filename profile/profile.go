@@ -0,0 +1,213 @@
+// Package profile counts the instructions a program executes, per source line and per Module or
+// Function, so students can compare how much work different algorithms do.
+package profile
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/ast"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Func is the profile of one Module or Function, or the global block.
+type Func struct {
+	Name      string
+	Line      int   // 0-based line where it's declared
+	Calls     int64 // times called
+	Exclusive int64 // instructions executed in the function itself
+	Inclusive int64 // instructions executed in the function and everything it called
+
+	active  int   // activations on the stack, for recursion
+	entered int64 // the total when the outermost activation began
+}
+
+// Profiler collects a profile; install [Profiler.Hook] as the [asm.ExecutionContext] Hook, then
+// call [Profiler.Finish] after the program stops.
+type Profiler struct {
+	Total    int64         // instructions executed
+	MaxDepth int           // the deepest the call stack got
+	Lines    map[int]int64 // instructions executed per 0-based source line
+
+	funcs    map[*ast.Scope]*Func
+	root     *node   // the top of the calling context tree
+	stack    []*node // parallel to the execution's stack
+	lastLine int
+}
+
+// node is a calling context: a function, reached through a particular chain of calls.
+type node struct {
+	fn       *Func
+	callLine int // the line in the parent that made the call
+	children map[nodeKey]*node
+	lines    map[int]int64 // instructions executed per line in this context
+}
+
+type nodeKey struct {
+	fn       *Func
+	callLine int
+}
+
+func New() *Profiler {
+	return &Profiler{
+		Lines: map[int]int64{},
+		funcs: map[*ast.Scope]*Func{},
+		root:  &node{children: map[nodeKey]*node{}},
+	}
+}
+
+// Hook counts an instruction about to execute.
+func (pr *Profiler) Hook(p *asm.Execution, inst asm.Inst) {
+	// the previous instruction may have called or returned
+	for len(pr.stack) > len(p.Stack) {
+		pr.pop()
+	}
+	for len(pr.stack) < len(p.Stack) {
+		pr.push(p.Stack[len(pr.stack)].Scope)
+	}
+
+	line := inst.GetSourceInfo().Start.Line
+	top := pr.stack[len(pr.stack)-1]
+	pr.Total++
+	pr.Lines[line]++
+	top.fn.Exclusive++
+	top.lines[line]++
+	pr.lastLine = line
+}
+
+// Finish accounts for any calls still on the stack when the program stopped.
+func (pr *Profiler) Finish() {
+	for len(pr.stack) > 0 {
+		pr.pop()
+	}
+}
+
+func (pr *Profiler) push(scope *ast.Scope) {
+	fn := pr.funcs[scope]
+	if fn == nil {
+		fn = &Func{Name: funcName(scope), Line: scope.SourceInfo.Start.Line}
+		if c := callable(scope); c != nil {
+			fn.Line = c.GetSourceInfo().Start.Line
+		}
+		pr.funcs[scope] = fn
+	}
+
+	parent := pr.root
+	if len(pr.stack) > 0 {
+		parent = pr.stack[len(pr.stack)-1]
+	}
+	key := nodeKey{fn: fn, callLine: pr.lastLine}
+	n := parent.children[key]
+	if n == nil {
+		n = &node{
+			fn:       fn,
+			callLine: pr.lastLine,
+			children: map[nodeKey]*node{},
+			lines:    map[int]int64{},
+		}
+		parent.children[key] = n
+	}
+
+	fn.Calls++
+	if fn.active == 0 {
+		fn.entered = pr.Total
+	}
+	fn.active++
+	pr.stack = append(pr.stack, n)
+	pr.MaxDepth = max(pr.MaxDepth, len(pr.stack))
+}
+
+func (pr *Profiler) pop() {
+	n := pr.stack[len(pr.stack)-1]
+	pr.stack = pr.stack[:len(pr.stack)-1]
+	fn := n.fn
+	fn.active--
+	if fn.active == 0 {
+		// only the outermost activation of a recursive function counts toward inclusive
+		fn.Inclusive += pr.Total - fn.entered
+	}
+}
+
+// Funcs returns the profiled functions, most expensive first.
+func (pr *Profiler) Funcs() []*Func {
+	var ret []*Func
+	for _, fn := range pr.funcs {
+		ret = append(ret, fn)
+	}
+	slices.SortFunc(ret, func(a, b *Func) int {
+		if c := cmp.Compare(b.Inclusive, a.Inclusive); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Exclusive, a.Exclusive); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return ret
+}
+
+// WriteListing writes the source with the instructions executed on each line.
+func (pr *Profiler) WriteListing(w io.Writer, src string) error {
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, text := range lines {
+		var err error
+		if n := pr.Lines[i]; n > 0 {
+			_, err = fmt.Fprintf(w, "%12d %5.1f%% %4d  %s\n", n, pr.percent(n), i+1, text)
+		} else {
+			_, err = fmt.Fprintf(w, "%12s %6s %4d  %s\n", "", "", i+1, text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTable writes a table of functions, most expensive first, with totals.
+func (pr *Profiler) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(tw, "calls\texclusive\t\tinclusive\t\t\tfunction")
+	for _, fn := range pr.Funcs() {
+		_, _ = fmt.Fprintf(tw, "%d\t%d\t%.1f%%\t%d\t%.1f%%\t\t%s\n",
+			fn.Calls, fn.Exclusive, pr.percent(fn.Exclusive), fn.Inclusive, pr.percent(fn.Inclusive), fn.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d instructions, max stack depth %d\n", pr.Total, pr.MaxDepth)
+	return err
+}
+
+func (pr *Profiler) percent(n int64) float64 {
+	if pr.Total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(pr.Total)
+}
+
+func callable(scope *ast.Scope) ast.Callable {
+	if scope.ModuleStmt != nil {
+		return scope.ModuleStmt
+	} else if scope.FunctionStmt != nil {
+		return scope.FunctionStmt
+	}
+	return nil
+}
+
+func funcName(scope *ast.Scope) string {
+	c := callable(scope)
+	if c == nil {
+		return scope.Desc()
+	}
+	name := c.GetName()
+	if enc := c.GetEnclosing(); enc != nil {
+		if name == "new$" {
+			return "New " + enc.GetName()
+		}
+		return enc.GetName() + "." + name
+	}
+	return name
+}
@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
+	"github.com/dragonsinth/gaddis/lib"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const src = `Function Integer fact(Integer n)
+    If n <= 1 Then
+        Return 1
+    End If
+    Return n * fact(n - 1)
+End Function
+
+Module main()
+    Declare Integer i
+    For i = 1 To 3
+        Display fact(i)
+    End For
+End Module
+`
+
+func TestProfile(t *testing.T) {
+	prog, _, errs := gaddis.Compile(src)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	pr := New()
+	var output bytes.Buffer
+	p := asmgen.Assemble(prog).NewExecution(&asm.ExecutionContext{
+		Rng:        rand.New(rand.NewSource(0)),
+		Clock:      lib.NewFakeClock(),
+		IoProvider: gaddis.IoAdapter{Out: gaddis.StreamOutput(&output)},
+		Hook:       pr.Hook,
+	})
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	pr.Finish()
+
+	if got := output.String(); got != "1\n2\n6\n" {
+		t.Errorf("got output %q", got)
+	}
+
+	var lineTotal int64
+	for _, n := range pr.Lines {
+		lineTotal += n
+	}
+	if lineTotal != pr.Total {
+		t.Errorf("lines add up to %d, want %d", lineTotal, pr.Total)
+	}
+
+	funcs := map[string]*Func{}
+	var exclusiveTotal int64
+	for _, fn := range pr.Funcs() {
+		funcs[fn.Name] = fn
+		exclusiveTotal += fn.Exclusive
+	}
+	if exclusiveTotal != pr.Total {
+		t.Errorf("exclusive adds up to %d, want %d", exclusiveTotal, pr.Total)
+	}
+	if fn := funcs["global"]; fn == nil || fn.Inclusive != pr.Total {
+		t.Errorf("global: got %+v, want inclusive %d", fn, pr.Total)
+	}
+	fact := funcs["fact"]
+	if fact == nil {
+		t.Fatal("missing fact")
+	}
+	if fact.Calls != 6 {
+		t.Errorf("fact: got %d calls, want 6", fact.Calls)
+	}
+	// recursive calls aren't counted twice, and fact calls nothing else
+	if fact.Inclusive != fact.Exclusive {
+		t.Errorf("fact: got inclusive %d, want %d", fact.Inclusive, fact.Exclusive)
+	}
+	if main := funcs["main"]; main.Inclusive != main.Exclusive+fact.Inclusive {
+		t.Errorf("main: got inclusive %d, want %d", main.Inclusive, main.Exclusive+fact.Inclusive)
+	}
+	// global, main, and fact(3) down to fact(1)
+	if pr.MaxDepth != 5 {
+		t.Errorf("got max depth %d, want 5", pr.MaxDepth)
+	}
+
+	var listing bytes.Buffer
+	if err := pr.WriteListing(&listing, src); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(listing.String(), "\n")
+	if got := strings.Fields(lines[6]); len(got) != 1 || got[0] != "7" {
+		t.Errorf("expected no count for blank line 7: %q", lines[6])
+	}
+	if got := strings.Fields(lines[10]); got[0] != strconv.FormatInt(pr.Lines[10], 10) || got[2] != "11" {
+		t.Errorf("expected a count for line 11: %q", lines[10])
+	}
+
+	var pprof bytes.Buffer
+	if err := pr.WritePprof(&pprof, "fact.gad"); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&pprof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"instructions", "fact.gad", "fact", "main", "global"} {
+		if !bytes.Contains(raw, []byte(s)) {
+			t.Errorf("expected %q in the profile's string table", s)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Package repl compiles and runs Gaddis code one entry at a time, for an interactive session.
+//
+// Each entry is parsed and compiled as a separate program against a persistent global scope,
+// then assembled onto the end of a persistent assembly and run in the global frame of a
+// persistent execution, so variables, modules, functions, and classes carry over between entries.
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/collect"
+	"github.com/dragonsinth/gaddis/controlflow"
+	"github.com/dragonsinth/gaddis/parse"
+	"github.com/dragonsinth/gaddis/typecheck"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrIncomplete is returned, wrapping the syntax errors, for an entry that ends before all of its
+// blocks are closed, such as an If without an End If. The caller should read more lines.
+var ErrIncomplete = errors.New("incomplete entry")
+
+// Errors are the compile errors in an entry.
+type Errors []ast.Error
+
+func (e Errors) Error() string {
+	var sb strings.Builder
+	for i, err := range e {
+		if i > 0 {
+			sb.WriteRune('\n')
+		}
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Result is the outcome of a successfully run entry.
+type Result struct {
+	Type     ast.Type // for an expression, its type; nil for statements and declarations
+	Value    any      // for an expression, its value
+	Exited   bool     // the entry called exit
+	ExitCode int
+}
+
+// String formats an expression's value for display, or returns "" for statements.
+func (r Result) String() string {
+	if r.Type == nil {
+		return ""
+	}
+	return FormatValue(r.Type, r.Value)
+}
+
+// Var describes a global variable or constant.
+type Var struct {
+	Name  string
+	Type  ast.Type
+	Const bool
+	Value string
+}
+
+func (v Var) String() string {
+	if v.Const {
+		return fmt.Sprintf("Constant %s %s = %s", v.Type, v.Name, v.Value)
+	}
+	return fmt.Sprintf("Declare %s %s = %s", v.Type, v.Name, v.Value)
+}
+
+// Session holds the state of an interactive session.
+type Session struct {
+	ec       *asm.ExecutionContext
+	types    map[ast.TypeKey]ast.Type
+	scope    *ast.Scope
+	assembly *asm.Assembly
+	exec     *asm.Execution
+}
+
+// New creates an empty session whose programs run with the given context.
+func New(ec *asm.ExecutionContext) *Session {
+	s := &Session{ec: ec}
+	s.Reset()
+	return s
+}
+
+// Reset forgets all declarations and values.
+func (s *Session) Reset() {
+	s.types = map[ast.TypeKey]ast.Type{}
+	s.scope = ast.NewGlobalScope(&ast.Block{})
+	s.assembly = &asm.Assembly{GlobalScope: s.scope}
+	s.exec = s.assembly.NewExecution(s.ec)
+	s.exec.Frame.Eval = make([]any, 0, 16)
+}
+
+// Eval compiles and runs one entry: an expression, whose value is returned, or any number of
+// statements and declarations.
+func (s *Session) Eval(src string) (Result, error) {
+	if !strings.HasSuffix(src, "\n") {
+		src += "\n" // a statement can't continue past the end of a line
+	}
+	expr, exprErr := parse.ParseWholeExpr(src, s.types)
+	if exprErr == nil {
+		return s.evalExpr(expr)
+	}
+
+	// parsing a class can modify previously seen class types
+	undo := s.snapshot()
+	prog, _, errs := parse.ParseWithTypes(src, s.types)
+	if len(errs) > 0 {
+		undo()
+		errs = ast.ErrorSort(errs)
+		if isIncomplete(errs) {
+			return Result{}, fmt.Errorf("%w: %w", ErrIncomplete, Errors(errs))
+		}
+		if errs[0].Start.Pos == 0 {
+			// not a statement at all; more likely a bad expression
+			return Result{}, exprErr
+		}
+		return Result{}, Errors(errs)
+	}
+	if len(prog.Block.Statements) == 0 {
+		return Result{}, nil
+	}
+
+	start, err := s.compile(prog, undo)
+	if err != nil {
+		return Result{}, err
+	}
+	return s.run(start)
+}
+
+// TypeOf returns the type of an expression, without evaluating it.
+func (s *Session) TypeOf(src string) (ast.Type, error) {
+	expr, err := s.checkExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	return expr.GetType(), nil
+}
+
+// Vars returns the global variables and constants, sorted by name.
+func (s *Session) Vars() []Var {
+	var ret []Var
+	for _, d := range s.scope.Decls {
+		vd := d.VarDecl
+		if vd == nil || strings.Contains(vd.Name, "$") {
+			continue // not a variable, or a temp
+		}
+		v := Var{Name: vd.Name, Type: vd.Type, Const: vd.IsConst}
+		if vd.IsConst {
+			v.Value = FormatValue(vd.Type, vd.Expr.ConstEval())
+		} else {
+			v.Value = FormatValue(vd.Type, s.exec.Stack[0].Locals[vd.Id])
+		}
+		ret = append(ret, v)
+	}
+	slices.SortFunc(ret, func(a, b Var) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return ret
+}
+
+// FormatValue formats a value the way it would be written in Gaddis source, where possible.
+func FormatValue(typ ast.Type, val any) string {
+	if r, ok := val.(rune); ok && typ == ast.Character {
+		return strconv.QuoteRune(r)
+	}
+	return asm.DebugStringVal(typ, val)
+}
+
+func (s *Session) checkExpr(src string) (ast.Expression, error) {
+	expr, err := parse.ParseWholeExpr(src, s.types)
+	if err != nil {
+		return nil, err
+	}
+	if errs := typecheck.TypeCheck(expr, s.scope); len(errs) > 0 {
+		return nil, Errors(errs)
+	}
+	return expr, nil
+}
+
+func (s *Session) evalExpr(expr ast.Expression) (Result, error) {
+	if errs := typecheck.TypeCheck(expr, s.scope); len(errs) > 0 {
+		return Result{}, Errors(errs)
+	}
+	start := len(s.assembly.Code)
+	s.assembly.Code = append(s.assembly.Code, asmgen.AssembleExpression(s.assembly, expr)...)
+	res, err := s.run(start)
+	if err != nil || res.Exited {
+		return res, err
+	}
+	res.Type = expr.GetType()
+	res.Value = s.exec.Pop()
+	return res, nil
+}
+
+// compile adds the program's declarations to the session and assembles it, returning the pc of its
+// global statements. If the program fails to compile, undo rolls back its declarations.
+func (s *Session) compile(prog *ast.Program, undo func()) (start int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+		if err != nil {
+			undo()
+		}
+	}()
+
+	if errs := collect.CollectInto(prog, s.scope); len(errs) > 0 {
+		return 0, Errors(ast.ErrorSort(errs))
+	}
+	if errs := typecheck.SuperCheck(prog); len(errs) > 0 {
+		return 0, Errors(ast.ErrorSort(errs))
+	}
+	if errs := typecheck.TypeCheck(prog, s.scope); len(errs) > 0 {
+		return 0, Errors(ast.ErrorSort(errs))
+	}
+	if errs := controlflow.ControlFlow(prog); len(errs) > 0 {
+		return 0, Errors(ast.ErrorSort(errs))
+	}
+	return asmgen.AssembleIncremental(s.assembly, prog), nil
+}
+
+// snapshot records the session's compile state, returning a func that restores it.
+func (s *Session) snapshot() func() {
+	types := maps.Clone(s.types)
+	classTypes := map[*ast.ClassType]ast.ClassType{}
+	for _, t := range types {
+		if ct := t.AsClassType(); ct != nil {
+			classTypes[ct] = *ct
+		}
+	}
+	decls := maps.Clone(s.scope.Decls)
+	nLocals := len(s.scope.Locals)
+	nClasses := len(s.scope.Classes)
+	assembly := *s.assembly
+	assembly.Labels = maps.Clone(s.assembly.Labels)
+	return func() {
+		s.types = types
+		for ct, saved := range classTypes {
+			*ct = saved
+		}
+		s.scope.Decls = decls
+		s.scope.Locals = s.scope.Locals[:nLocals]
+		s.scope.Classes = s.scope.Classes[:nClasses]
+		*s.assembly = assembly
+	}
+}
+
+// run executes code starting at pc in the global frame.
+func (s *Session) run(pc int) (Result, error) {
+	p := s.exec
+	global := &p.Stack[0]
+	for len(global.Locals) < len(s.scope.Locals) {
+		global.Locals = append(global.Locals, nil)
+	}
+	p.Code = s.assembly.Code
+	p.PC = pc
+	p.Frame = global
+
+	err := p.Run()
+
+	var res Result
+	if err == nil {
+		// the halt that stopped execution was the last instruction run
+		if h, ok := p.Code[p.PC-1].(asm.Halt); ok && h.Exit {
+			res.Exited = true
+			res.ExitCode = p.ExitCode
+		}
+	}
+	if err != nil || res.Exited {
+		// unwind anything left behind
+		p.Stack = p.Stack[:1]
+		p.Stack[0].Eval = p.Stack[0].Eval[:0]
+	}
+	// calls may have reallocated the stack
+	p.Frame = &p.Stack[0]
+	return res, err
+}
+
+// isIncomplete reports whether the syntax errors are only due to running out of input.
+func isIncomplete(errs []ast.Error) bool {
+	for _, err := range errs {
+		if strings.Contains(err.Desc, "got EOF") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package repl
+
+import (
+	"bytes"
+	"errors"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/ast"
+	"github.com/dragonsinth/gaddis/lib"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func newTestSession(output *bytes.Buffer) *Session {
+	return New(&asm.ExecutionContext{
+		Rng:   rand.New(rand.NewSource(0)),
+		Clock: lib.NewFakeClock(),
+		IoProvider: gaddis.IoAdapter{
+			In:  gaddis.StreamInput(strings.NewReader("")),
+			Out: gaddis.StreamOutput(output),
+		},
+	})
+}
+
+func TestSession(t *testing.T) {
+	var output bytes.Buffer
+	s := newTestSession(&output)
+
+	tcs := []struct {
+		entry  string
+		want   string // the expression's value
+		output string
+	}{
+		{entry: "Declare Integer x = 2"},
+		{entry: "Constant Real PI = 3.14"},
+		{entry: "x * 3", want: "6"},
+		{entry: "Set x = x + 1\nDisplay x", output: "3\n"},
+		{entry: "Function Integer double(Integer n)\n  Return n * 2\nEnd Function"},
+		{entry: "double(x)", want: "6"},
+		{entry: "Module greet(String name)\n  Display \"Hello, \", name\nEnd Module"},
+		{entry: "Call greet(\"world\")", output: "Hello, world\n"},
+		{entry: "Class Pet\n  Public String name\nEnd Class"},
+		{entry: "Class Dog Extends Pet\nEnd Class"},
+		{entry: "Declare Pet p = New Dog()\nSet p.name = \"Rex\""},
+		{entry: "p.name", want: `"Rex"`},
+		{entry: "Declare Integer nums[3] = 1, 2, 3\nDeclare Integer total = 0, n\nFor Each n In nums\n  Set total = total + n\nEnd For"},
+		{entry: "total", want: "6"},
+		{entry: "'a'", want: "'a'"},
+		{entry: "x > 2 AND PI < 4", want: "True"},
+	}
+	for _, tc := range tcs {
+		output.Reset()
+		res, err := s.Eval(tc.entry)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.entry, err)
+		}
+		if got := res.String(); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.entry, got, tc.want)
+		}
+		if got := output.String(); got != tc.output {
+			t.Errorf("%q: got output %q, want %q", tc.entry, got, tc.output)
+		}
+	}
+
+	var names []string
+	for _, v := range s.Vars() {
+		names = append(names, v.String())
+	}
+	want := []string{
+		`Constant Real PI = 3.14`,
+		`Declare Integer n = 3`,
+		`Declare Integer[] nums = Integer[3]`,
+		`Declare Pet p = <Dog>`,
+		`Declare Integer total = 6`,
+		`Declare Integer x = 3`,
+	}
+	if got := strings.Join(names, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("vars: got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if typ, err := s.TypeOf("double(x) / 2.0"); err != nil {
+		t.Error(err)
+	} else if typ != ast.Real {
+		t.Errorf("got type %s, want Real", typ)
+	}
+
+	s.Reset()
+	if _, err := s.Eval("x"); err == nil {
+		t.Error("expected x to be undefined after reset")
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	var output bytes.Buffer
+	s := newTestSession(&output)
+
+	for _, entry := range []string{
+		"If True Then\n  Display 1",
+		"Module foo()",
+		"Declare Integer nums[3] = 1,",
+		"Select 1\n  Case 1:",
+		"Do\n  Display 1",
+		"Class A",
+		"Class A\n  Public Integer v",
+	} {
+		if _, err := s.Eval(entry); !errors.Is(err, ErrIncomplete) {
+			t.Errorf("%q: expected incomplete, got %v", entry, err)
+		}
+	}
+
+	// a real syntax error is never incomplete
+	if _, err := s.Eval("Display 1 +\nDisplay 2"); err == nil || errors.Is(err, ErrIncomplete) {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+
+	if _, err := s.Eval("If True Then\n  Display 1\nEnd If"); err != nil {
+		t.Fatal(err)
+	}
+	if got := output.String(); got != "1\n" {
+		t.Errorf("got output %q", got)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	var output bytes.Buffer
+	s := newTestSession(&output)
+
+	// the second declaration fails to type check, so neither should be kept
+	if _, err := s.Eval("Declare Integer a = 1\nDeclare Integer b = \"two\""); err == nil {
+		t.Fatal("expected a type error")
+	}
+	if _, err := s.Eval("Declare String a = \"one\"\nDeclare String b = \"two\""); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.Eval("append(a, b)"); err != nil {
+		t.Fatal(err)
+	} else if got := res.String(); got != `"onetwo"` {
+		t.Errorf("got %s", got)
+	}
+
+	// redeclaring is an error
+	if _, err := s.Eval("Declare Integer a"); err == nil {
+		t.Error("expected a redeclaration error")
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	var output bytes.Buffer
+	s := newTestSession(&output)
+
+	if _, err := s.Eval("Declare Integer zero = 0, x = 1"); err != nil {
+		t.Fatal(err)
+	}
+	var re *asm.RuntimeError
+	if _, err := s.Eval("Function Integer f()\n  Return x / zero\nEnd Function\nDisplay f()"); !errors.As(err, &re) {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+	// the session is still usable
+	if res, err := s.Eval("x + 1"); err != nil {
+		t.Fatal(err)
+	} else if got := res.String(); got != "2" {
+		t.Errorf("got %s", got)
+	}
+
+	res, err := s.Eval("Call exit(3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exited || res.ExitCode != 3 {
+		t.Errorf("got %+v, want exit code 3", res)
+	}
+}
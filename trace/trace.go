@@ -0,0 +1,129 @@
+// Package trace logs what a program does as it runs: the source lines it executes, the calls it
+// makes, or every instruction.
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/ast"
+	"io"
+	"strings"
+)
+
+// Tracer writes trace events; install [Tracer.Hook] as the [asm.ExecutionContext] Hook.
+type Tracer struct {
+	Lines bool            // each source line as it begins executing
+	Calls bool            // calls with their arguments, and returns with their values
+	Insts bool            // each instruction with the eval stack
+	Funcs map[string]bool // if set, only trace these Modules and Functions ("global" for the global block)
+
+	w        io.Writer
+	filename string
+	src      []string
+	lastLine int
+	lastPc   int // the start of the function the last line was in
+	lastLen  int // the stack depth the last line was at
+}
+
+// New creates a tracer for the given source file, writing to w. Mode is a comma-separated list of
+// "lines", "calls", and "insts".
+func New(w io.Writer, filename string, src string, mode string) (*Tracer, error) {
+	t := &Tracer{
+		w:        w,
+		filename: filename,
+		src:      strings.Split(src, "\n"),
+		lastLine: -1,
+	}
+	for _, m := range strings.Split(mode, ",") {
+		switch strings.TrimSpace(m) {
+		case "lines":
+			t.Lines = true
+		case "calls":
+			t.Calls = true
+		case "insts":
+			t.Insts = true
+		default:
+			return nil, fmt.Errorf("unknown trace mode %q; expected lines, calls, or insts", m)
+		}
+	}
+	if !t.Lines && !t.Calls && !t.Insts {
+		return nil, errors.New("empty trace mode")
+	}
+	return t, nil
+}
+
+// Hook traces an instruction about to execute.
+func (t *Tracer) Hook(p *asm.Execution, inst asm.Inst) {
+	fr := p.Frame
+	line := inst.GetSourceInfo().Start.Line
+	depth := len(p.Stack) - 1
+	traced := t.traced(fr.Scope)
+
+	if t.Calls && traced && !fr.Scope.IsGlobal {
+		switch inst := inst.(type) {
+		case asm.Begin:
+			t.printf(line, depth, "call %s", asm.FormatFrameScope(fr))
+		case asm.Return:
+			if inst.NVal == 1 {
+				var typ ast.Type = ast.UnresolvedType
+				if fs := fr.Scope.FunctionStmt; fs != nil {
+					typ = fs.Type
+				}
+				t.printf(line, depth, "return %s = %s", asm.FormatFrameScope(fr), asm.DebugStringVal(typ, fr.Eval[0]))
+			} else {
+				t.printf(line, depth, "return %s", asm.FormatFrameScope(fr))
+			}
+		case asm.End:
+			t.printf(line, depth, "return %s", asm.FormatFrameScope(fr))
+		}
+	}
+
+	if t.Lines && traced && !isBeginEnd(inst) {
+		if line != t.lastLine || fr.Start != t.lastPc || len(p.Stack) != t.lastLen {
+			var text string
+			if line < len(t.src) {
+				text = strings.TrimSpace(t.src[line])
+			}
+			t.printf(line, depth, "%s", text)
+		}
+	}
+	t.lastLine, t.lastPc, t.lastLen = line, fr.Start, len(p.Stack)
+
+	if t.Insts && traced {
+		var sb strings.Builder
+		for i, v := range fr.Eval {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(asm.DebugStringVal(ast.UnresolvedType, v))
+		}
+		t.printf(line, depth, "%s: %-24s [%s]", asm.PcRef(p.PC), inst, sb.String())
+	}
+}
+
+// isBeginEnd reports whether inst is a function's begin or end, which aren't source lines as such.
+func isBeginEnd(inst asm.Inst) bool {
+	switch inst.(type) {
+	case asm.Begin, asm.End:
+		return true
+	}
+	return false
+}
+
+func (t *Tracer) traced(scope *ast.Scope) bool {
+	if len(t.Funcs) == 0 {
+		return true
+	}
+	name := "global"
+	if scope.ModuleStmt != nil {
+		name = scope.ModuleStmt.Name
+	} else if scope.FunctionStmt != nil {
+		name = scope.FunctionStmt.Name
+	}
+	return t.Funcs[name]
+}
+
+func (t *Tracer) printf(line int, depth int, format string, args ...any) {
+	_, _ = fmt.Fprintf(t.w, "%s:%d: %s%s\n", t.filename, line+1, strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,96 @@
+package trace
+
+import (
+	"bytes"
+	"github.com/dragonsinth/gaddis"
+	"github.com/dragonsinth/gaddis/asm"
+	"github.com/dragonsinth/gaddis/asmgen"
+	"github.com/dragonsinth/gaddis/lib"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const src = `Function Integer double(Integer n)
+    Return n * 2
+End Function
+
+Module show(Integer n)
+    Display double(n)
+End Module
+
+Call show(2)
+`
+
+func runTrace(t *testing.T, mode string, funcs ...string) string {
+	t.Helper()
+	prog, _, errs := gaddis.Compile(src)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	var out, output bytes.Buffer
+	tr, err := New(&out, "double.gad", src, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) > 0 {
+		tr.Funcs = map[string]bool{}
+		for _, f := range funcs {
+			tr.Funcs[f] = true
+		}
+	}
+	p := asmgen.Assemble(prog).NewExecution(&asm.ExecutionContext{
+		Rng:        rand.New(rand.NewSource(0)),
+		Clock:      lib.NewFakeClock(),
+		IoProvider: gaddis.IoAdapter{Out: gaddis.StreamOutput(&output)},
+		Hook:       tr.Hook,
+	})
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got := output.String(); got != "4\n" {
+		t.Errorf("got output %q", got)
+	}
+	return out.String()
+}
+
+func TestLinesAndCalls(t *testing.T) {
+	got := runTrace(t, "lines,calls")
+	want := `double.gad:9: Call show(2)
+double.gad:5:   call Module show(2)
+double.gad:6:   Display double(n)
+double.gad:1:     call Function Integer double(2)
+double.gad:2:     Return n * 2
+double.gad:2:     return Function Integer double(2) = 4
+double.gad:6:   Display double(n)
+double.gad:7:   return Module show(2)
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	got := runTrace(t, "calls", "double")
+	want := `double.gad:1:     call Function Integer double(2)
+double.gad:2:     return Function Integer double(2) = 4
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInsts(t *testing.T) {
+	got := runTrace(t, "insts", "double")
+	for _, want := range []string{"begin(1,0) :double", "mul int", "[2, 2]", "return(1)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestBadMode(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "x.gad", "", "lines,bogus"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -1,8 +1,12 @@
 package typecheck
 
 import (
+	"fmt"
 	"github.com/dragonsinth/gaddis/ast"
 	"github.com/dragonsinth/gaddis/base"
+	"math"
+	"slices"
+	"strings"
 )
 
 // TODO(scottb):
@@ -90,8 +94,23 @@ func (v *Visitor) PostVisitSetStmt(ss *ast.SetStmt) {
 	} else if !ast.CanCoerce(refType, exprType) {
 		v.Errorf(ss.Expr, "%s not assignable to %s", exprType, refType)
 	} else if refType.IsArrayType() {
-		v.Errorf(ss.Expr, "arrays cannot be assigned to")
+		// the whole array is replaced by a copy; sizes must agree, checked here where they are known
+		// and at runtime otherwise
+		dst, src := ast.StaticDims(ss.Ref), ast.StaticDims(ss.Expr)
+		if dst != nil && src != nil && !slices.Equal(dst, src) {
+			base := refType.BaseType()
+			v.Errorf(ss.Expr, "array size mismatch: %s not assignable to %s", formatDims(base, src), formatDims(base, dst))
+		}
+	}
+}
+
+func formatDims(base ast.Type, dims []int) string {
+	var sb strings.Builder
+	sb.WriteString(base.String())
+	for _, d := range dims {
+		_, _ = fmt.Fprintf(&sb, "[%d]", d)
 	}
+	return sb.String()
 }
 
 func (v *Visitor) PostVisitOpenStmt(os *ast.OpenStmt) {
@@ -106,6 +125,18 @@ func (v *Visitor) PostVisitOpenStmt(os *ast.OpenStmt) {
 	if os.Name.GetType() != ast.String {
 		v.Errorf(os, "expected String; got %s", os.Name)
 	}
+	if file.GetType() == ast.RandomFile {
+		if os.Format != ast.GaddisFormat {
+			v.Errorf(os, "RandomFile is a binary file and cannot be opened As %s", os.Format)
+		}
+		if os.RecordSize == nil {
+			v.Errorf(os, "RandomFile requires a record size, e.g. Open file \"name\", 64")
+		} else if os.RecordSize.GetType() != ast.Integer {
+			v.Errorf(os.RecordSize, "expected Integer record size; got %s", os.RecordSize.GetType())
+		}
+	} else if os.RecordSize != nil && file.GetType().IsFileType() {
+		v.Errorf(os.RecordSize, "only a RandomFile has a record size")
+	}
 }
 
 func (v *Visitor) PostVisitCloseStmt(cs *ast.CloseStmt) {
@@ -366,12 +397,29 @@ func (v *Visitor) PostVisitUnaryOperation(uo *ast.UnaryOperation) {
 		if ok && !typ.IsNumeric() {
 			v.Errorf(uo.Expr, "operator %s expects operand of type %s to be numeric", op, typ)
 		}
+		if val := uo.Expr.ConstEval(); val == int64(math.MinInt64) {
+			v.Errorf(uo, "integer overflow: -(%d)", val)
+		}
 	default:
 		panic(op)
 	}
 	uo.Type = typ
 }
 
+// checkConstArithmetic reports overflow or division by zero in constant arithmetic at compile time.
+func (v *Visitor) checkConstArithmetic(bo *ast.BinaryOperation) {
+	if !bo.ArgType.IsNumeric() {
+		return
+	}
+	lhs, rhs := bo.Lhs.ConstEval(), bo.Rhs.ConstEval()
+	if lhs == nil || rhs == nil {
+		return
+	}
+	if err := ast.CheckOp(bo.Op, bo.ArgType.AsPrimitive(), lhs, rhs); err != nil {
+		v.Errorf(bo, "%s", err)
+	}
+}
+
 func (v *Visitor) PostVisitBinaryOperation(bo *ast.BinaryOperation) {
 	aTyp := bo.Lhs.GetType()
 	bTyp := bo.Rhs.GetType()
@@ -395,6 +443,7 @@ func (v *Visitor) PostVisitBinaryOperation(bo *ast.BinaryOperation) {
 			}
 			bo.Type = rTyp
 			bo.ArgType = rTyp
+			v.checkConstArithmetic(bo)
 		}
 	case ast.EQ, ast.NEQ:
 		rTyp := ast.AreComparableTypes(aTyp, bTyp)
@@ -498,9 +547,72 @@ func (v *Visitor) PostVisitCallExpr(ce *ast.CallExpr) {
 		return // accepts any value
 	}
 
+	if len(ce.Ref.Overloads) > 0 {
+		fs := resolveOverload(ce.Args, ce.Ref.Overloads)
+		if fs == nil {
+			var argTypes []string
+			for _, arg := range ce.Args {
+				if arg.GetType() == ast.UnresolvedType {
+					return // reduce error spam
+				}
+				argTypes = append(argTypes, arg.GetType().String())
+			}
+			v.Errorf(ce, "no overload of %s accepts (%s)", ce.Name, strings.Join(argTypes, ", "))
+			return
+		}
+		ce.Ref = fs
+		ce.Type = ce.Ref.Type
+	}
+
+	if n := len(ce.Ref.Params); ce.Ref.IsVariadic && len(ce.Args) > n {
+		v.checkArgumentList(ce, ce.Args[:n], ce.Ref.Params)
+		for i, arg := range ce.Args[n:] {
+			if !arg.GetType().IsPrimitive() {
+				v.Errorf(arg, "argument %d: expected a primitive value, got %s", n+i+1, arg.GetType())
+			}
+		}
+		return
+	}
+
 	v.checkArgumentList(ce, ce.Args, ce.Ref.Params)
 }
 
+// resolveOverload picks the first candidate whose parameters exactly match the arguments,
+// else the first candidate the arguments can be coerced to, else nil.
+func resolveOverload(args []ast.Expression, candidates []*ast.FunctionStmt) *ast.FunctionStmt {
+	matches := func(fs *ast.FunctionStmt, exact bool) bool {
+		if len(fs.Params) != len(args) {
+			return false
+		}
+		for i, param := range fs.Params {
+			argType := args[i].GetType()
+			if exact && !ast.IsSameType(param.Type, argType) {
+				return false
+			} else if !exact && !canPass(param, argType) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, exact := range []bool{true, false} {
+		for _, fs := range candidates {
+			if matches(fs, exact) {
+				return fs
+			}
+		}
+	}
+	return nil
+}
+
+// canPass reports whether an argument of the given type can be passed by value to param.
+func canPass(param *ast.VarDecl, argType ast.Type) bool {
+	if param.Type == ast.UnresolvedType {
+		// only externals have untyped params, which accept any array
+		return argType.IsArrayType()
+	}
+	return ast.CanCoerce(param.Type, argType)
+}
+
 func (v *Visitor) PostVisitArrayRef(ar *ast.ArrayRef) {
 	if indexTyp := ar.IndexExpr.GetType(); indexTyp != ast.UnresolvedType && indexTyp != ast.Integer {
 		v.Errorf(ar.IndexExpr, "index expression must be of type Integer")
@@ -575,8 +687,12 @@ func (v *Visitor) qualifiedLookup(qual ast.Expression, name string) *ast.Decl {
 func (v *Visitor) checkArgumentList(si ast.HasSourceInfo, args []ast.Expression, params []*ast.VarDecl) {
 	for i, c := 0, min(len(args), len(params)); i < c; i++ {
 		arg, param := args[i], params[i]
-		if !ast.CanCoerce(param.Type, arg.GetType()) {
-			v.Errorf(arg, "argument %d: %s not assignable to %s", i+1, arg.GetType(), param.Type)
+		if !canPass(param, arg.GetType()) {
+			if param.Type == ast.UnresolvedType {
+				v.Errorf(arg, "argument %d: expected an array, got %s", i+1, arg.GetType())
+			} else {
+				v.Errorf(arg, "argument %d: %s not assignable to %s", i+1, arg.GetType(), param.Type)
+			}
 		}
 		if param.IsRef {
 			// must be an exact type match for reference
@@ -587,11 +703,9 @@ func (v *Visitor) checkArgumentList(si ast.HasSourceInfo, args []ast.Expression,
 				}
 			} else if !arg.CanReference() {
 				v.Errorf(arg, "argument %d: expression must be a reference", i+1)
-			} else if arg.GetType() != param.Type {
+			} else if !ast.IsSameType(arg.GetType(), param.Type) {
 				v.Errorf(arg, "argument %d: %s not assignable to %s", i+1, arg.GetType(), param.Type)
 			}
-		} else if !ast.CanCoerce(param.Type, arg.GetType()) {
-			v.Errorf(arg, "argument %d: %s not assignable to %s", i+1, arg.GetType(), param.Type)
 		}
 	}
 	if len(args) != len(params) {
@@ -0,0 +1,28 @@
+package typecheck_test
+
+import (
+	"github.com/dragonsinth/gaddis"
+	"strings"
+	"testing"
+)
+
+func TestOverloadErrors(t *testing.T) {
+	tcs := []struct {
+		src  string
+		want string // empty if it compiles
+	}{
+		{"Declare String s[2]\nDisplay sum(s)\n", "no overload of sum accepts (String[])"},
+		{"Declare Integer a[2]\nDisplay max(a, 1)\n", "no overload of max accepts (Integer[], Integer)"},
+		{"Declare Real r[2]\nDisplay sum(r)\n", ""},
+	}
+	for _, tc := range tcs {
+		_, _, errs := gaddis.Compile(tc.src)
+		if tc.want == "" {
+			if len(errs) > 0 {
+				t.Errorf("%q: unexpected errors: %v", tc.src, errs)
+			}
+		} else if len(errs) != 1 || !strings.Contains(errs[0].Desc, tc.want) {
+			t.Errorf("%q: got %v, want %q", tc.src, errs, tc.want)
+		}
+	}
+}
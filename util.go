@@ -2,19 +2,24 @@ package gaddis
 
 import (
 	"bufio"
+	"github.com/dragonsinth/gaddis/lib"
 	"io"
 )
 
 type IoProvider interface {
 	Input() (string, error)
 	Output(string)
+	Print(string)
 	Dir() string
+	InputPolicy() lib.InputPolicy
 }
 
 type IoAdapter struct {
 	In      func() (string, error)
 	Out     func(string)
+	Prn     func(string) // printer output; if nil, printer output goes to Out
 	WorkDir string
+	Policy  lib.InputPolicy
 }
 
 func (i IoAdapter) Input() (string, error) {
@@ -25,10 +30,22 @@ func (i IoAdapter) Output(s string) {
 	i.Out(s)
 }
 
+func (i IoAdapter) Print(s string) {
+	if i.Prn != nil {
+		i.Prn(s)
+	} else {
+		i.Out(s)
+	}
+}
+
 func (i IoAdapter) Dir() string {
 	return i.WorkDir
 }
 
+func (i IoAdapter) InputPolicy() lib.InputPolicy {
+	return i.Policy
+}
+
 func StreamOutput(w io.Writer) func(string) {
 	return func(s string) {
 		_, _ = w.Write([]byte(s))
@@ -3,7 +3,23 @@ import * as path from 'path';
 import { dapServerPort } from "./dapServer";
 
 export function activateDebug(context: vscode.ExtensionContext) {
+    // Print statement output is sent in the 'printer' category; show it in its own channel.
+    const printerChannel = vscode.window.createOutputChannel('Gaddis Printer');
     context.subscriptions.push(
+        printerChannel,
+        vscode.debug.registerDebugAdapterTrackerFactory('gaddis', {
+            createDebugAdapterTracker(_session: vscode.DebugSession) {
+                return {
+                    onDidSendMessage(message: any) {
+                        if (message.type === 'event' && message.event === 'output' && message.body?.category === 'printer') {
+                            printerChannel.append(message.body.output);
+                            printerChannel.show(true);
+                        }
+                    },
+                };
+            },
+        }),
+
         vscode.debug.registerDebugConfigurationProvider('gaddis', {
             resolveDebugConfiguration(folder: vscode.WorkspaceFolder | undefined, config: vscode.DebugConfiguration, token?: vscode.CancellationToken): vscode.ProviderResult<vscode.DebugConfiguration> {
                 // if launch.json is missing or empty